@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"auth/internal/breach"
+	"auth/internal/bulk"
+	"auth/internal/passwordhash"
+)
+
+// runCommand runs a maintenance subcommand instead of starting the server.
+func runCommand(name string, args []string) {
+	switch name {
+	case "build-breach-filter":
+		buildBreachFilter(args)
+	case "import-users":
+		importUsers(args)
+	case "export-users":
+		exportUsers(args)
+	default:
+		log.Fatalf("unknown command %q", name)
+	}
+}
+
+func buildBreachFilter(args []string) {
+	flags := flag.NewFlagSet("build-breach-filter", flag.ExitOnError)
+	input := flags.String("in", "", "sorted HIBP hash:count file to read")
+	output := flags.String("out", "", "bloom filter file to write, should end in .bloom")
+	minCount := flags.Uint("min-count", 1, "only include hashes seen at least this many times")
+	falsePositiveRate := flags.Float64("fp-rate", 0.001, "target false positive rate")
+	flags.Parse(args)
+
+	if *input == "" || *output == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *falsePositiveRate <= 0 || *falsePositiveRate >= 1 {
+		log.Fatal("fp-rate must be between 0 and 1")
+	}
+
+	stats, err := breach.BuildFilter(*input, *output, uint32(*minCount), *falsePositiveRate)
+	if err != nil {
+		log.Fatalf("failed to build breach filter: %v", err)
+	}
+
+	log.Printf("Read %d hashes, wrote %d to %s (%d bytes)", stats.Lines, stats.Included, *output, stats.Bytes)
+}
+
+// openCommandDB connects to DATABASE_URL, the only config the data commands
+// need.
+func openCommandDB() *sql.DB {
+	databaseUrl := os.Getenv("DATABASE_URL")
+	if databaseUrl == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", databaseUrl)
+	if err != nil {
+		log.Fatalf("failed opening connection to postgres: %v", err)
+	}
+	return db
+}
+
+func importUsers(args []string) {
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	format := flags.String("format", "jsonl", "input format, csv or jsonl")
+	dryRun := flags.Bool("dry-run", false, "validate every row without writing anything")
+	flags.Parse(args)
+
+	parsedFormat, err := bulk.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Read from the named file, or stdin if there isn't one
+	var input io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			log.Fatalf("failed to open input: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	db := openCommandDB()
+	defer db.Close()
+
+	// Only used to recognise hash formats, so the cost parameters don't matter
+	hasher := passwordhash.NewHasher(passwordhash.Config{})
+	report, err := bulk.NewImporter(db, hasher.Recognizes).Import(input, parsedFormat, *dryRun)
+	if err != nil {
+		log.Fatalf("import failed after %d rows: %v", report.Total, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func exportUsers(args []string) {
+	flags := flag.NewFlagSet("export-users", flag.ExitOnError)
+	format := flags.String("format", "jsonl", "output format, csv or jsonl")
+	output := flags.String("out", "", "file to write, defaults to stdout")
+	flags.Parse(args)
+
+	parsedFormat, err := bulk.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var writer io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("failed to create output: %v", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	db := openCommandDB()
+	defer db.Close()
+
+	count, err := bulk.NewExporter(db).Export(writer, parsedFormat)
+	if err != nil {
+		log.Fatalf("export failed after %d users: %v", count, err)
+	}
+
+	log.Printf("Exported %d users", count)
+}
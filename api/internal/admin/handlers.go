@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"auth/internal/audit"
+	"auth/internal/bulk"
+	"auth/internal/httputil"
+	"auth/internal/jobs"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+func (s *AdminService) ListAuditEvents(adminID ulid.ULID, filter repositories.AuditEventFilter, meta httputil.RequestMeta) (audit.ListResponse, error) {
+	response, err := s.auditLogger.List(filter)
+	if err != nil {
+		return audit.ListResponse{}, err
+	}
+
+	query := map[string]any{"event_types": filter.EventTypes}
+	if filter.UserID != nil {
+		query["user_id"] = ulidutil.ToPrefixed("user", *filter.UserID)
+	}
+	if filter.From != nil {
+		query["from"] = filter.From
+	}
+	if filter.To != nil {
+		query["to"] = filter.To
+	}
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminAuditEventsQueried,
+		ActorID:  &adminID,
+		UserID:   filter.UserID,
+		Request:  meta,
+		Metadata: query,
+	})
+
+	return response, nil
+}
+
+type LockResponse struct {
+	UserID              string     `json:"user_id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	FailedLoginAttempts int32      `json:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `json:"last_failed_login_at"`
+	LockedUntil         *time.Time `json:"locked_until"`
+}
+
+func (s *AdminService) ListLocks() ([]LockResponse, error) {
+	users, err := s.userRepo.ListLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]LockResponse, len(users))
+	for i, user := range users {
+		response[i] = LockResponse{
+			UserID:              ulidutil.ToPrefixed("user", ulidutil.MustFromBytes(user.ID)),
+			Username:            user.Username,
+			Email:               user.Email,
+			FailedLoginAttempts: user.FailedLoginAttempts,
+			LastFailedLoginAt:   user.LastFailedLoginAt,
+			LockedUntil:         user.LockedUntil,
+		}
+	}
+
+	return response, nil
+}
+
+func (s *AdminService) ClearLock(adminID ulid.ULID, userID ulid.ULID, meta httputil.RequestMeta) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.ClearLockout(userID); err != nil {
+			return err
+		}
+
+		accountUnlockTokenRepo := repositories.NewAccountUnlockTokenRepository(tx)
+		return accountUnlockTokenRepo.RevokeByUserID(userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAdminAccountUnlocked,
+		ActorID: &adminID,
+		UserID:  &userID,
+		Request: meta,
+		Metadata: map[string]any{
+			"failed_login_attempts": user.FailedLoginAttempts,
+			"locked_until":          user.LockedUntil,
+		},
+	})
+
+	return nil
+}
+
+// ListJobs returns the last run and outcome of each maintenance job.
+func (s *AdminService) ListJobs() ([]jobs.Status, error) {
+	return s.scheduler.Status()
+}
+
+func (s *AdminService) ImportUsers(adminID ulid.ULID, body io.Reader, format bulk.Format, dryRun bool, meta httputil.RequestMeta) (bulk.Report, error) {
+	report, err := s.importer.Import(body, format, dryRun)
+	if err != nil {
+		return bulk.Report{}, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAdminUsersImported,
+		ActorID: &adminID,
+		Request: meta,
+		Metadata: map[string]any{
+			"format":   format,
+			"dry_run":  dryRun,
+			"total":    report.Total,
+			"imported": report.Imported,
+			"failed":   report.Failed,
+		},
+	})
+
+	return report, nil
+}
@@ -0,0 +1,403 @@
+package admin
+
+import (
+	"auth/internal/audit"
+	"auth/internal/auth"
+	"auth/internal/bulk"
+	"auth/internal/httputil"
+	"auth/internal/middleware"
+	"auth/internal/ulidutil"
+	"crypto/ed25519"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+// Larger migrations should use the import-users command instead
+const maxImportBytes = 64 << 20
+
+func Router(s *AdminService) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Auth(s.jwtAccessKey.Public().(ed25519.PublicKey), s.issuer))
+	r.Use(middleware.RequireAdmin(s.userRepo))
+
+	r.Get("/audit-events", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+		adminID, err := ulid.Parse(ctx.Subject)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		filter, err := audit.ParseFilter(r.URL.Query())
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		response, err := s.ListAuditEvents(adminID, filter, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, response)
+	})
+
+	r.Get("/locks", func(w http.ResponseWriter, r *http.Request) {
+		response, err := s.ListLocks()
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, response)
+	})
+
+	r.Delete("/users/{userID}/lock", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+		adminID, err := ulid.Parse(ctx.Subject)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := ulidutil.FromPrefixed("user", chi.URLParam(r, "userID"))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		err = s.ClearLock(adminID, userID, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.Get("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		response, err := s.ListJobs()
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, response)
+	})
+
+	r.Route("/scim/tenants", func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			var body CreateScimTenantParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			response, err := s.CreateScimTenant(adminID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusCreated, response)
+		})
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			response, err := s.ListScimTenants()
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Delete("/{tenantID}", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			tenantID, err := ulidutil.FromPrefixed("tenant", chi.URLParam(r, "tenantID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			err = s.DeleteScimTenant(adminID, tenantID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.Post("/{tenantID}/tokens", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			tenantID, err := ulidutil.FromPrefixed("tenant", chi.URLParam(r, "tenantID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.CreateScimToken(adminID, tenantID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusCreated, response)
+		})
+
+		r.Get("/{tenantID}/tokens", func(w http.ResponseWriter, r *http.Request) {
+			tenantID, err := ulidutil.FromPrefixed("tenant", chi.URLParam(r, "tenantID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.ListScimTokens(tenantID)
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Delete("/{tenantID}/tokens/{tokenID}", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			tenantID, err := ulidutil.FromPrefixed("tenant", chi.URLParam(r, "tenantID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+			tokenID, err := ulidutil.FromPrefixed("scimtoken", chi.URLParam(r, "tokenID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			err = s.RevokeScimToken(adminID, tenantID, tokenID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			var body CreateWebhookParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			response, err := s.CreateWebhook(adminID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusCreated, response)
+		})
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			response, err := s.ListWebhooks()
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Get("/{webhookID}", func(w http.ResponseWriter, r *http.Request) {
+			webhookID, err := ulidutil.FromPrefixed("webhook", chi.URLParam(r, "webhookID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.GetWebhook(webhookID)
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Patch("/{webhookID}", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			webhookID, err := ulidutil.FromPrefixed("webhook", chi.URLParam(r, "webhookID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			var body UpdateWebhookParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			response, err := s.UpdateWebhook(adminID, webhookID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Delete("/{webhookID}", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			webhookID, err := ulidutil.FromPrefixed("webhook", chi.URLParam(r, "webhookID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			err = s.DeleteWebhook(adminID, webhookID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.Get("/{webhookID}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+			webhookID, err := ulidutil.FromPrefixed("webhook", chi.URLParam(r, "webhookID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			filter, err := ParseWebhookDeliveryFilter(r.URL.Query())
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.ListWebhookDeliveries(webhookID, filter)
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Post("/{webhookID}/deliveries/{deliveryID}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			adminID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			webhookID, err := ulidutil.FromPrefixed("webhook", chi.URLParam(r, "webhookID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+			deliveryID, err := ulidutil.FromPrefixed("delivery", chi.URLParam(r, "deliveryID"))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.RedeliverWebhook(adminID, webhookID, deliveryID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusAccepted, response)
+		})
+	})
+
+	r.Post("/users/import", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+		adminID, err := ulid.Parse(ctx.Subject)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		// The format can be given explicitly or inferred from the content type
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "jsonl"
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+				format = "csv"
+			}
+		}
+		parsedFormat, err := bulk.ParseFormat(format)
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+		body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+		report, err := s.ImportUsers(adminID, body, parsedFormat, dryRun, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, report)
+	})
+
+	return r
+}
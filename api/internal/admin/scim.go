@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/auth"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/ulidutil"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+type CreateScimTenantParams struct {
+	Name string `json:"name"`
+}
+
+type ScimTenantResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toScimTenantResponse(tenant model.ScimTenants) ScimTenantResponse {
+	return ScimTenantResponse{
+		ID:        ulidutil.ToPrefixed("tenant", ulidutil.MustFromBytes(tenant.ID)),
+		Name:      tenant.Name,
+		CreatedAt: tenant.CreatedAt,
+	}
+}
+
+func (s *AdminService) CreateScimTenant(adminID ulid.ULID, params CreateScimTenantParams, meta httputil.RequestMeta) (ScimTenantResponse, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return ScimTenantResponse{}, apperror.NewBadRequest("Name is required")
+	}
+
+	exists, err := s.scimTenantRepo.NameExists(name)
+	if err != nil {
+		return ScimTenantResponse{}, err
+	}
+	if exists {
+		return ScimTenantResponse{}, apperror.NewConflict("Tenant name already in use")
+	}
+
+	tenant := model.ScimTenants{
+		ID:        ulid.Make().Bytes(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := s.scimTenantRepo.Create(tenant); err != nil {
+		return ScimTenantResponse{}, err
+	}
+
+	response := toScimTenantResponse(tenant)
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminScimTenantCreated,
+		ActorID:  &adminID,
+		Request:  meta,
+		Metadata: map[string]any{"tenant_id": response.ID, "name": name},
+	})
+
+	return response, nil
+}
+
+func (s *AdminService) ListScimTenants() ([]ScimTenantResponse, error) {
+	tenants, err := s.scimTenantRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]ScimTenantResponse, len(tenants))
+	for i, tenant := range tenants {
+		response[i] = toScimTenantResponse(tenant)
+	}
+	return response, nil
+}
+
+// DeleteScimTenant removes the tenant along with its tokens and groups. Users
+// it provisioned are kept but can no longer be managed over SCIM.
+func (s *AdminService) DeleteScimTenant(adminID ulid.ULID, tenantID ulid.ULID, meta httputil.RequestMeta) error {
+	tenant, err := s.scimTenantRepo.GetByID(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.scimTenantRepo.Delete(tenantID); err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminScimTenantDeleted,
+		ActorID:  &adminID,
+		Request:  meta,
+		Metadata: map[string]any{"tenant_id": ulidutil.ToPrefixed("tenant", tenantID), "name": tenant.Name},
+	})
+
+	return nil
+}
+
+type ScimTokenResponse struct {
+	ID         string     `json:"id"`
+	Token      string     `json:"token,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func toScimTokenResponse(token model.ScimTokens) ScimTokenResponse {
+	return ScimTokenResponse{
+		ID:         ulidutil.ToPrefixed("scimtoken", ulidutil.MustFromBytes(token.ID)),
+		LastUsedAt: token.LastUsedAt,
+		RevokedAt:  token.RevokedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// CreateScimToken issues a bearer token for the tenant's identity provider.
+// Only its hash is stored, so the response is the one chance to see it.
+func (s *AdminService) CreateScimToken(adminID ulid.ULID, tenantID ulid.ULID, meta httputil.RequestMeta) (ScimTokenResponse, error) {
+	if _, err := s.scimTenantRepo.GetByID(tenantID); err != nil {
+		return ScimTokenResponse{}, err
+	}
+
+	token, hashedToken := auth.GenerateResetToken()
+	scimToken := model.ScimTokens{
+		ID:        ulid.Make().Bytes(),
+		TenantID:  tenantID.Bytes(),
+		TokenHash: hashedToken,
+		CreatedAt: time.Now(),
+	}
+	if err := s.scimTokenRepo.Create(scimToken); err != nil {
+		return ScimTokenResponse{}, err
+	}
+
+	response := toScimTokenResponse(scimToken)
+	response.Token = auth.URLEncodeToken(token)
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAdminScimTokenCreated,
+		ActorID: &adminID,
+		Request: meta,
+		Metadata: map[string]any{
+			"tenant_id": ulidutil.ToPrefixed("tenant", tenantID),
+			"token_id":  response.ID,
+		},
+	})
+
+	return response, nil
+}
+
+func (s *AdminService) ListScimTokens(tenantID ulid.ULID) ([]ScimTokenResponse, error) {
+	if _, err := s.scimTenantRepo.GetByID(tenantID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.scimTokenRepo.ListByTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]ScimTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = toScimTokenResponse(token)
+	}
+	return response, nil
+}
+
+func (s *AdminService) RevokeScimToken(adminID ulid.ULID, tenantID ulid.ULID, tokenID ulid.ULID, meta httputil.RequestMeta) error {
+	revoked, err := s.scimTokenRepo.Revoke(tenantID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return apperror.NewNotFound("Token not found")
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAdminScimTokenRevoked,
+		ActorID: &adminID,
+		Request: meta,
+		Metadata: map[string]any{
+			"tenant_id": ulidutil.ToPrefixed("tenant", tenantID),
+			"token_id":  ulidutil.ToPrefixed("scimtoken", tokenID),
+		},
+	})
+
+	return nil
+}
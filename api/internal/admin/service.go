@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"auth/internal/audit"
+	"auth/internal/bulk"
+	"auth/internal/jobs"
+	"auth/internal/passwordhash"
+	"auth/internal/repositories"
+	"crypto/ed25519"
+	"database/sql"
+)
+
+type AdminService struct {
+	db           *sql.DB
+	jwtAccessKey ed25519.PrivateKey
+	issuer       string
+	auditLogger  *audit.Logger
+	importer     *bulk.Importer
+	scheduler    *jobs.Scheduler
+
+	userRepo                repositories.UserRepository
+	scimTenantRepo          repositories.ScimTenantRepository
+	scimTokenRepo           repositories.ScimTokenRepository
+	webhookSubscriptionRepo repositories.WebhookSubscriptionRepository
+	webhookDeliveryRepo     repositories.WebhookDeliveryRepository
+}
+
+func NewAdminService(db *sql.DB, jwtAccessKey ed25519.PrivateKey, issuer string, auditLogger *audit.Logger, passwordHasher *passwordhash.Hasher, scheduler *jobs.Scheduler) (*AdminService, error) {
+	return &AdminService{
+		db:                      db,
+		jwtAccessKey:            jwtAccessKey,
+		issuer:                  issuer,
+		auditLogger:             auditLogger,
+		importer:                bulk.NewImporter(db, passwordHasher.Recognizes),
+		scheduler:               scheduler,
+		userRepo:                repositories.NewUserRepository(db),
+		scimTenantRepo:          repositories.NewScimTenantRepository(db),
+		scimTokenRepo:           repositories.NewScimTokenRepository(db),
+		webhookSubscriptionRepo: repositories.NewWebhookSubscriptionRepository(db),
+		webhookDeliveryRepo:     repositories.NewWebhookDeliveryRepository(db),
+	}, nil
+}
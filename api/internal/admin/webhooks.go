@@ -0,0 +1,356 @@
+package admin
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	defaultDeliveryListLimit = 50
+	maxDeliveryListLimit     = 200
+)
+
+type CreateWebhookParams struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type UpdateWebhookParams struct {
+	URL        *string   `json:"url"`
+	EventTypes *[]string `json:"event_types"`
+	Active     *bool     `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	// Only returned when the subscription is created
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toWebhookResponse(subscription model.WebhookSubscriptions) WebhookResponse {
+	var eventTypes []string
+	json.Unmarshal([]byte(subscription.EventTypes), &eventTypes)
+
+	return WebhookResponse{
+		ID:         ulidutil.ToPrefixed("webhook", ulidutil.MustFromBytes(subscription.ID)),
+		URL:        subscription.URL,
+		EventTypes: eventTypes,
+		Active:     subscription.Active,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func validateWebhookURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", apperror.NewBadRequest("URL must be an absolute http or https URL")
+	}
+	return rawURL, nil
+}
+
+// encodeEventTypes checks every type is known and returns them as a JSON
+// array without duplicates.
+func encodeEventTypes(eventTypes []string) (string, error) {
+	if len(eventTypes) == 0 {
+		return "", apperror.NewBadRequest("At least one event type is required")
+	}
+
+	var unique []string
+	for _, eventType := range eventTypes {
+		if !webhooks.IsEventType(eventType) {
+			return "", apperror.NewBadRequest("Unknown event type " + strconv.Quote(eventType) + ", expected one of " + strings.Join(webhooks.EventTypes, ", "))
+		}
+		if !slices.Contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+
+	encoded, _ := json.Marshal(unique)
+	return string(encoded), nil
+}
+
+func (s *AdminService) CreateWebhook(adminID ulid.ULID, params CreateWebhookParams, meta httputil.RequestMeta) (WebhookResponse, error) {
+	webhookURL, err := validateWebhookURL(params.URL)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+	eventTypes, err := encodeEventTypes(params.EventTypes)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+
+	now := time.Now()
+	subscription := model.WebhookSubscriptions{
+		ID:         ulid.Make().Bytes(),
+		URL:        webhookURL,
+		Secret:     webhooks.GenerateSecret(),
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.webhookSubscriptionRepo.Create(subscription); err != nil {
+		return WebhookResponse{}, err
+	}
+
+	response := toWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminWebhookCreated,
+		ActorID:  &adminID,
+		Request:  meta,
+		Metadata: map[string]any{"webhook_id": response.ID, "url": response.URL, "event_types": response.EventTypes},
+	})
+
+	return response, nil
+}
+
+func (s *AdminService) ListWebhooks() ([]WebhookResponse, error) {
+	subscriptions, err := s.webhookSubscriptionRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]WebhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = toWebhookResponse(subscription)
+	}
+	return response, nil
+}
+
+func (s *AdminService) getWebhook(webhookID ulid.ULID) (*model.WebhookSubscriptions, error) {
+	subscription, err := s.webhookSubscriptionRepo.GetByID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, apperror.NewNotFound("Webhook not found")
+	}
+	return subscription, nil
+}
+
+func (s *AdminService) GetWebhook(webhookID ulid.ULID) (WebhookResponse, error) {
+	subscription, err := s.getWebhook(webhookID)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+	return toWebhookResponse(*subscription), nil
+}
+
+func (s *AdminService) UpdateWebhook(adminID ulid.ULID, webhookID ulid.ULID, params UpdateWebhookParams, meta httputil.RequestMeta) (WebhookResponse, error) {
+	subscription, err := s.getWebhook(webhookID)
+	if err != nil {
+		return WebhookResponse{}, err
+	}
+
+	if params.URL != nil {
+		subscription.URL, err = validateWebhookURL(*params.URL)
+		if err != nil {
+			return WebhookResponse{}, err
+		}
+	}
+	if params.EventTypes != nil {
+		subscription.EventTypes, err = encodeEventTypes(*params.EventTypes)
+		if err != nil {
+			return WebhookResponse{}, err
+		}
+	}
+	if params.Active != nil {
+		subscription.Active = *params.Active
+	}
+
+	if err := s.webhookSubscriptionRepo.Update(*subscription); err != nil {
+		return WebhookResponse{}, err
+	}
+	subscription.UpdatedAt = time.Now()
+
+	response := toWebhookResponse(*subscription)
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminWebhookUpdated,
+		ActorID:  &adminID,
+		Request:  meta,
+		Metadata: map[string]any{"webhook_id": response.ID, "url": response.URL, "event_types": response.EventTypes, "active": response.Active},
+	})
+
+	return response, nil
+}
+
+func (s *AdminService) DeleteWebhook(adminID ulid.ULID, webhookID ulid.ULID, meta httputil.RequestMeta) error {
+	deleted, err := s.webhookSubscriptionRepo.Delete(webhookID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperror.NewNotFound("Webhook not found")
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAdminWebhookDeleted,
+		ActorID:  &adminID,
+		Request:  meta,
+		Metadata: map[string]any{"webhook_id": ulidutil.ToPrefixed("webhook", webhookID)},
+	})
+
+	return nil
+}
+
+type WebhookDeliveryFilter struct {
+	Status string
+	Before *ulid.ULID
+	Limit  int64
+}
+
+// ParseWebhookDeliveryFilter reads the status, before and limit query
+// parameters.
+func ParseWebhookDeliveryFilter(query url.Values) (WebhookDeliveryFilter, error) {
+	filter := WebhookDeliveryFilter{Limit: defaultDeliveryListLimit}
+
+	switch status := query.Get("status"); status {
+	case "", repositories.WebhookDeliveryPending, repositories.WebhookDeliveryDelivered, repositories.WebhookDeliveryFailed:
+		filter.Status = status
+	default:
+		return filter, apperror.NewBadRequest("Invalid status, expected pending, delivered or failed")
+	}
+
+	if before := query.Get("before"); before != "" {
+		id, err := ulidutil.FromPrefixed("delivery", before)
+		if err != nil {
+			return filter, err
+		}
+		filter.Before = &id
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 1 {
+			return filter, apperror.NewBadRequest("Invalid limit")
+		}
+		filter.Limit = min(n, maxDeliveryListLimit)
+	}
+
+	return filter, nil
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus *int32          `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	NextCursor *string                   `json:"next_cursor"`
+}
+
+func toWebhookDeliveryResponse(delivery model.WebhookDeliveries) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             ulidutil.ToPrefixed("delivery", ulidutil.MustFromBytes(delivery.ID)),
+		EventID:        ulidutil.ToPrefixed("event", ulidutil.MustFromBytes(delivery.EventID)),
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt,
+	}
+	// The next attempt is meaningless once a delivery has settled
+	if delivery.Status == repositories.WebhookDeliveryPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return response
+}
+
+func (s *AdminService) ListWebhookDeliveries(webhookID ulid.ULID, filter WebhookDeliveryFilter) (WebhookDeliveryListResponse, error) {
+	if _, err := s.getWebhook(webhookID); err != nil {
+		return WebhookDeliveryListResponse{}, err
+	}
+
+	deliveries, err := s.webhookDeliveryRepo.ListBySubscription(webhookID, filter.Status, filter.Before, filter.Limit)
+	if err != nil {
+		return WebhookDeliveryListResponse{}, err
+	}
+
+	response := WebhookDeliveryListResponse{Deliveries: make([]WebhookDeliveryResponse, len(deliveries))}
+	for i, delivery := range deliveries {
+		response.Deliveries[i] = toWebhookDeliveryResponse(delivery)
+	}
+	if int64(len(deliveries)) == filter.Limit && len(deliveries) > 0 {
+		response.NextCursor = &response.Deliveries[len(deliveries)-1].ID
+	}
+
+	return response, nil
+}
+
+// RedeliverWebhook queues a fresh copy of a delivery with the same event ID
+// and payload. The original stays in the log as it was.
+func (s *AdminService) RedeliverWebhook(adminID ulid.ULID, webhookID ulid.ULID, deliveryID ulid.ULID, meta httputil.RequestMeta) (WebhookDeliveryResponse, error) {
+	original, err := s.webhookDeliveryRepo.GetByID(webhookID, deliveryID)
+	if err != nil {
+		return WebhookDeliveryResponse{}, err
+	}
+	if original == nil {
+		return WebhookDeliveryResponse{}, apperror.NewNotFound("Delivery not found")
+	}
+
+	now := time.Now()
+	delivery := model.WebhookDeliveries{
+		ID:             ulid.Make().Bytes(),
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         repositories.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	if err := s.webhookDeliveryRepo.Create(delivery); err != nil {
+		return WebhookDeliveryResponse{}, err
+	}
+
+	response := toWebhookDeliveryResponse(delivery)
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAdminWebhookRedelivered,
+		ActorID: &adminID,
+		Request: meta,
+		Metadata: map[string]any{
+			"webhook_id":           ulidutil.ToPrefixed("webhook", webhookID),
+			"original_delivery_id": ulidutil.ToPrefixed("delivery", deliveryID),
+			"delivery_id":          response.ID,
+		},
+	})
+
+	return response, nil
+}
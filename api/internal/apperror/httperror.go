@@ -25,6 +25,62 @@ func (e *Error) StatusCode() int {
 	return e.Status
 }
 
+// DetailedError is an HTTPError that is rendered as a JSON body
+type DetailedError interface {
+	HTTPError
+	Body() any
+}
+
+// Violation describes a single failed validation rule
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is a 422 listing every rule the input failed
+type ValidationError struct {
+	Message    string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s\n", e.Message)
+}
+
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+func (e *ValidationError) Body() any {
+	return struct {
+		Error      string      `json:"error"`
+		Violations []Violation `json:"violations"`
+	}{e.Message, e.Violations}
+}
+
+// CodedError is an HTTPError rendered as a JSON body with a stable code that
+// clients can react to, unlike the message
+type CodedError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *CodedError) Error() string {
+	return fmt.Sprintf("%s\n", e.Message)
+}
+
+func (e *CodedError) StatusCode() int {
+	return e.Status
+}
+
+func (e *CodedError) Body() any {
+	return struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{e.Message, e.Code}
+}
+
 // New creates a new HTTPError with the given status and message
 func New(status int, msg string) HTTPError {
 	return &Error{Status: status, Message: msg}
@@ -58,6 +114,12 @@ func NewForbidden(msg string) HTTPError {
 	return &Error{Status: http.StatusForbidden, Message: msg}
 }
 
+// Forbidden (403) because the operation needs a recent login, which the
+// client can get from POST /auth/reauthenticate
+func NewReauthenticationRequired() HTTPError {
+	return &CodedError{Status: http.StatusForbidden, Code: "reauthentication_required", Message: "Recent authentication required"}
+}
+
 // Not Found (404)
 func NewNotFound(msg string) HTTPError {
 	if msg == "" {
@@ -88,6 +150,14 @@ func NewUnprocessableEntity(msg string) HTTPError {
 	return &Error{Status: http.StatusUnprocessableEntity, Message: msg}
 }
 
+// Unprocessable Entity (422) with the list of failed rules
+func NewValidationError(msg string, violations []Violation) HTTPError {
+	if msg == "" {
+		msg = "Unprocessable Entity"
+	}
+	return &ValidationError{Message: msg, Violations: violations}
+}
+
 // Too Many Requests (429)
 func NewTooManyRequests(msg string) HTTPError {
 	if msg == "" {
@@ -0,0 +1,264 @@
+package audit
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	EventUserRegistered          = "user.registered"
+	EventRegistrationDuplicate   = "user.registration_duplicate"
+	EventLoginSucceeded          = "auth.login_succeeded"
+	EventLoginFailed             = "auth.login_failed"
+	EventTokenRefreshed          = "auth.token_refreshed"
+	EventReauthenticated         = "auth.reauthenticated"
+	EventReauthenticationFailed  = "auth.reauthentication_failed"
+	EventPasswordResetRequested  = "auth.password_reset_requested"
+	EventPasswordReset           = "auth.password_reset"
+	EventEmailVerified           = "user.email_verified"
+	EventVerificationEmailSent   = "user.verification_email_sent"
+	EventEmailChangeRequested    = "user.email_change_requested"
+	EventEmailChanged            = "user.email_changed"
+	EventEmailChangeCancelled    = "user.email_change_cancelled"
+	EventProfileUpdated          = "user.profile_updated"
+	EventPasswordChanged         = "user.password_changed"
+	EventUserDeleted             = "user.deleted"
+	EventDeletionScheduled       = "user.deletion_scheduled"
+	EventDeletionCancelled       = "user.deletion_cancelled"
+	EventDataExportRequested     = "user.data_export_requested"
+	EventDataExportDownloaded    = "user.data_export_downloaded"
+	EventAccountLocked           = "auth.account_locked"
+	EventAccountUnlocked         = "auth.account_unlocked"
+	EventAdminAuditEventsQueried = "admin.audit_events_queried"
+	EventAdminAccountUnlocked    = "admin.account_unlocked"
+	EventAdminUsersImported      = "admin.users_imported"
+	EventAdminScimTenantCreated  = "admin.scim_tenant_created"
+	EventAdminScimTenantDeleted  = "admin.scim_tenant_deleted"
+	EventAdminScimTokenCreated   = "admin.scim_token_created"
+	EventAdminScimTokenRevoked   = "admin.scim_token_revoked"
+	EventAdminWebhookCreated     = "admin.webhook_created"
+	EventAdminWebhookUpdated     = "admin.webhook_updated"
+	EventAdminWebhookDeleted     = "admin.webhook_deleted"
+	EventAdminWebhookRedelivered = "admin.webhook_redelivered"
+	EventScimUserProvisioned     = "scim.user_provisioned"
+	EventScimUserUpdated         = "scim.user_updated"
+	EventScimUserDeactivated     = "scim.user_deactivated"
+	EventScimUserDeleted         = "scim.user_deleted"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type Event struct {
+	Type     string
+	ActorID  *ulid.ULID
+	UserID   *ulid.ULID
+	Request  httputil.RequestMeta
+	Metadata map[string]any
+}
+
+type Logger struct {
+	auditEventRepo repositories.AuditEventRepository
+}
+
+func NewLogger(db *sql.DB) *Logger {
+	return &Logger{
+		auditEventRepo: repositories.NewAuditEventRepository(db),
+	}
+}
+
+// Record appends an event to the audit log. Failures are logged rather than
+// returned so that auditing never blocks the action being audited.
+func (l *Logger) Record(event Event) {
+	metadata := []byte("{}")
+	if event.Metadata != nil {
+		encoded, err := json.Marshal(event.Metadata)
+		if err != nil {
+			log.Printf("[ERROR] Failed to encode audit metadata for %s: %v", event.Type, err)
+		} else {
+			metadata = encoded
+		}
+	}
+
+	auditEventModel := model.AuditEvents{
+		ID:        ulid.Make().Bytes(),
+		ActorID:   optionalID(event.ActorID),
+		UserID:    optionalID(event.UserID),
+		EventType: event.Type,
+		IPAddress: optionalIP(event.Request.IP),
+		UserAgent: optionalString(event.Request.UserAgent),
+		RequestID: optionalString(event.Request.RequestID),
+		Metadata:  string(metadata),
+		CreatedAt: time.Now(),
+	}
+	l.auditEventRepo.Create(auditEventModel)
+}
+
+type EventResponse struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	ActorID   *string         `json:"actor_id"`
+	UserID    *string         `json:"user_id"`
+	IPAddress *string         `json:"ip_address"`
+	UserAgent *string         `json:"user_agent"`
+	RequestID *string         `json:"request_id"`
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ListResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+func (l *Logger) List(filter repositories.AuditEventFilter) (ListResponse, error) {
+	events, err := l.auditEventRepo.List(filter)
+	if err != nil {
+		return ListResponse{}, err
+	}
+
+	response := ListResponse{Events: make([]EventResponse, len(events))}
+	for i, event := range events {
+		response.Events[i] = toResponse(event)
+	}
+	if int64(len(events)) == filter.Limit && len(events) > 0 {
+		response.NextCursor = &response.Events[len(events)-1].ID
+	}
+
+	return response, nil
+}
+
+// ListAll returns every event about the user, newest first.
+func (l *Logger) ListAll(userID ulid.ULID) ([]EventResponse, error) {
+	filter := repositories.AuditEventFilter{UserID: &userID, Limit: maxListLimit}
+
+	var responses []EventResponse
+	for {
+		events, err := l.auditEventRepo.List(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			responses = append(responses, toResponse(event))
+		}
+		if int64(len(events)) < filter.Limit {
+			return responses, nil
+		}
+
+		before := ulidutil.MustFromBytes(events[len(events)-1].ID)
+		filter.Before = &before
+	}
+}
+
+// ParseFilter reads the user_id, type, from, to, before and limit query
+// parameters. type may be repeated or comma separated.
+func ParseFilter(query url.Values) (repositories.AuditEventFilter, error) {
+	filter := repositories.AuditEventFilter{Limit: defaultListLimit}
+
+	if userID := query.Get("user_id"); userID != "" {
+		id, err := ulidutil.FromPrefixed("user", userID)
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = &id
+	}
+
+	for _, value := range query["type"] {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				filter.EventTypes = append(filter.EventTypes, eventType)
+			}
+		}
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, apperror.NewBadRequest("Invalid " + name + " timestamp, expected RFC 3339")
+		}
+		*dst = &t
+	}
+
+	if before := query.Get("before"); before != "" {
+		id, err := ulidutil.FromPrefixed("audit", before)
+		if err != nil {
+			return filter, err
+		}
+		filter.Before = &id
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 1 {
+			return filter, apperror.NewBadRequest("Invalid limit")
+		}
+		filter.Limit = min(n, maxListLimit)
+	}
+
+	return filter, nil
+}
+
+func toResponse(event model.AuditEvents) EventResponse {
+	return EventResponse{
+		ID:        ulidutil.ToPrefixed("audit", ulidutil.MustFromBytes(event.ID)),
+		Type:      event.EventType,
+		ActorID:   prefixedUserID(event.ActorID),
+		UserID:    prefixedUserID(event.UserID),
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		RequestID: event.RequestID,
+		Metadata:  json.RawMessage(event.Metadata),
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+func prefixedUserID(id *[]byte) *string {
+	if id == nil {
+		return nil
+	}
+	prefixed := ulidutil.ToPrefixed("user", ulidutil.MustFromBytes(*id))
+	return &prefixed
+}
+
+func optionalID(id *ulid.ULID) *[]byte {
+	if id == nil {
+		return nil
+	}
+	b := id.Bytes()
+	return &b
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// optionalIP drops addresses Postgres would reject as inet so that a spoofed
+// X-Forwarded-For header cannot make the insert fail.
+func optionalIP(ip string) *string {
+	if net.ParseIP(ip) == nil {
+		return nil
+	}
+	return &ip
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/httputil"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"database/sql"
+	"time"
+)
+
+type CancelDeletionParams struct {
+	Token string `json:"token"`
+}
+
+// CancelDeletion keeps an account scheduled for deletion, using the token
+// emailed when the deletion was requested. The user signs in again afterwards.
+func (s *AuthService) CancelDeletion(params CancelDeletionParams, meta httputil.RequestMeta) error {
+	token, err := URLDecodeToken(params.Token)
+	if err != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	deletionToken, err := s.accountDeletionTokenRepo.GetByHash(HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if deletionToken.RevokedAt != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	if deletionToken.ExpiresAt.Before(time.Now()) {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	userID := ulidutil.MustFromBytes(deletionToken.UserID)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	tokenID := ulidutil.MustFromBytes(deletionToken.ID)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		accountDeletionTokenRepo := repositories.NewAccountDeletionTokenRepository(tx)
+		if err := accountDeletionTokenRepo.Revoke(tokenID); err != nil {
+			return err
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		cancelled, err := userRepo.CancelDeletion(userID)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return apperror.NewBadRequest("Invalid token")
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserDeletionCancelled,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventDeletionCancelled,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/httputil"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"database/sql"
+	"time"
+)
+
+type ConfirmEmailChangeParams struct {
+	Token string `json:"token"`
+}
+
+// ConfirmEmailChange switches the account to the new address of a change
+// request, using the token emailed to that address.
+func (s *AuthService) ConfirmEmailChange(params ConfirmEmailChangeParams, meta httputil.RequestMeta) error {
+	token, err := URLDecodeToken(params.Token)
+	if err != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	request, err := s.emailChangeRequestRepo.GetByConfirmHash(HashToken(token))
+	if err != nil {
+		return err
+	}
+	if request == nil || request.ConfirmedAt != nil || request.CancelledAt != nil || request.ExpiresAt.Before(time.Now()) {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	userID := ulidutil.MustFromBytes(request.UserID)
+	requestID := ulidutil.MustFromBytes(request.ID)
+
+	// The address may have been taken since the change was requested
+	other, err := s.userRepo.GetByEmail(request.NewEmail)
+	if err != nil {
+		return err
+	}
+	if other != nil && ulidutil.MustFromBytes(other.ID) != userID {
+		return apperror.NewConflict("Email already in use")
+	}
+
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		emailChangeRequestRepo := repositories.NewEmailChangeRequestRepository(tx)
+		confirmed, err := emailChangeRequestRepo.MarkConfirmed(requestID)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return apperror.NewBadRequest("Invalid token")
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.SetVerifiedEmail(userID, request.NewEmail); err != nil {
+			return err
+		}
+
+		// Verification links sent to the previous address no longer apply
+		emailVerificationTokenRepo := repositories.NewEmailVerificationTokenRepository(tx)
+		if err := emailVerificationTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserEmailChanged,
+			UserID: userID,
+			Data:   map[string]any{"email": request.NewEmail, "previous_email": request.PreviousEmail},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventEmailChanged,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"email": map[string]string{"from": request.PreviousEmail, "to": request.NewEmail}},
+	})
+
+	return nil
+}
+
+type CancelEmailChangeParams struct {
+	Token string `json:"token"`
+}
+
+// CancelEmailChange is the "this wasn't me" link sent to the previous
+// address. It cancels the change, or reverts it if it was already confirmed,
+// and signs the account out everywhere.
+func (s *AuthService) CancelEmailChange(params CancelEmailChangeParams, meta httputil.RequestMeta) error {
+	token, err := URLDecodeToken(params.Token)
+	if err != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	request, err := s.emailChangeRequestRepo.GetByCancelHash(HashToken(token))
+	if err != nil {
+		return err
+	}
+	if request == nil || request.CancelledAt != nil || request.CancelExpiresAt.Before(time.Now()) {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	userID := ulidutil.MustFromBytes(request.UserID)
+	requestID := ulidutil.MustFromBytes(request.ID)
+
+	revert := request.ConfirmedAt != nil
+	if revert {
+		other, err := s.userRepo.GetByEmail(request.PreviousEmail)
+		if err != nil {
+			return err
+		}
+		if other != nil && ulidutil.MustFromBytes(other.ID) != userID {
+			return apperror.NewConflict("Email already in use")
+		}
+	}
+
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		emailChangeRequestRepo := repositories.NewEmailChangeRequestRepository(tx)
+		cancelled, err := emailChangeRequestRepo.MarkCancelled(requestID)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return apperror.NewBadRequest("Invalid token")
+		}
+		if err := emailChangeRequestRepo.CancelPendingByUserID(userID); err != nil {
+			return err
+		}
+
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		if err := refreshTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		if !revert {
+			return nil
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.SetVerifiedEmail(userID, request.PreviousEmail); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserEmailChanged,
+			UserID: userID,
+			Data:   map[string]any{"email": request.PreviousEmail, "previous_email": request.NewEmail},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventEmailChangeCancelled,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"email": request.NewEmail, "reverted": revert},
+	})
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "time"
+
+// EnumerationPolicy stops the unauthenticated endpoints from revealing
+// whether an email address has an account. When Enabled, registering an
+// address that is taken looks like a successful registration and its owner is
+// emailed instead, logging in to a locked out account fails as if the
+// password were wrong, and the endpoints take at least MinResponseTime so
+// that the database and hashing work done only for known addresses can't be
+// timed.
+type EnumerationPolicy struct {
+	Enabled         bool
+	MinResponseTime time.Duration
+}
+
+// pad sleeps until MinResponseTime has passed since start. Requests that take
+// longer are not padded further, so MinResponseTime should be above the
+// slowest of them.
+func (p EnumerationPolicy) pad(start time.Time) {
+	if !p.Enabled {
+		return
+	}
+	if remaining := p.MinResponseTime - time.Since(start); remaining > 0 {
+		time.Sleep(remaining)
+	}
+}
@@ -2,11 +2,18 @@ package auth
 
 import (
 	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/httputil"
 	"auth/internal/jet/postgres/public/model"
+	"auth/internal/passwordpolicy"
+	"auth/internal/repositories"
 	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
 	"crypto/ed25519"
+	"database/sql"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/oklog/ulid/v2"
 )
 
@@ -14,12 +21,34 @@ type CreateUserParams struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Defaults to the best match for the request's Accept-Language header
+	Locale string `json:"locale,omitempty"`
 }
 
-func (s *AuthService) CreateUser(params CreateUserParams) error {
-	hash, err := HashPassword(params.Password)
+func (s *AuthService) CreateUser(params CreateUserParams, meta httputil.RequestMeta) ([]apperror.Violation, error) {
+	defer s.enumerationPolicy.pad(time.Now())
+
+	warnings, err := s.passwordPolicy.Validate(passwordpolicy.Candidate{
+		Password: params.Password,
+		Username: params.Username,
+		Email:    params.Email,
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	locale := s.emailService.MatchLocale(meta.AcceptLanguage)
+	if params.Locale != "" {
+		supported, ok := s.emailService.SupportedLocale(params.Locale)
+		if !ok {
+			return nil, apperror.NewBadRequest("Unsupported locale")
+		}
+		locale = supported
+	}
+
+	hash, err := s.passwordHasher.Hash(params.Password)
+	if err != nil {
+		return nil, err
 	}
 
 	user := model.Users{
@@ -27,38 +56,103 @@ func (s *AuthService) CreateUser(params CreateUserParams) error {
 		Username:      params.Username,
 		Email:         params.Email,
 		EmailVerified: false,
+		Active:        true,
 		PasswordHash:  hash,
+		Locale:        locale,
 	}
 
-	userExists, err := s.userRepo.WillConflict(user)
+	// The addresses and usernames of recently deleted accounts stay reserved
+	emailTombstoned, err := s.accountTombstoneRepo.Exists(repositories.AccountTombstoneEmail, user.Email)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if userExists {
-		return apperror.NewConflict("Username or email already in use")
+	usernameTombstoned, err := s.accountTombstoneRepo.Exists(repositories.AccountTombstoneUsername, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.enumerationPolicy.Enabled {
+		// Usernames aren't secret, but refusing one must not depend on the
+		// email, or pairing a taken username with an address would reveal
+		// whether the address has an account
+		usernameTaken, err := s.userRepo.UsernameExists(user.Username)
+		if err != nil {
+			return nil, err
+		}
+		if usernameTaken || usernameTombstoned {
+			return nil, apperror.NewConflict("Username already in use")
+		}
+
+		existing, err := s.userRepo.GetByEmail(user.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return warnings, s.notifyExistingAccount(existing, meta)
+		}
+		// There is nobody to notify, but it must still look like a success
+		if emailTombstoned {
+			return warnings, nil
+		}
 	}
 
-	err = s.userRepo.Create(user)
+	userExists, err := s.userRepo.WillConflict(user)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if userExists || emailTombstoned || usernameTombstoned {
+		return nil, apperror.NewConflict("Username or email already in use")
 	}
 
 	userID := ulidutil.MustFromBytes(user.ID)
-	s.emailVerificationTokenRepo.RevokeByUserID(userID)
 
-	token, hashedToken := GenerateResetToken()
-	emailVerificationTokenModel := model.EmailVerificationTokens{
-		ID:        ulid.Make().Bytes(),
-		UserID:    user.ID,
-		TokenHash: hashedToken,
-		ExpiresAt: time.Now().Add(time.Duration(24) * time.Hour),
-		RevokedAt: nil,
-		CreatedAt: time.Now(),
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.Create(user); err != nil {
+			return err
+		}
+
+		if err := s.passwordPolicy.Remember(tx, userID, hash); err != nil {
+			return err
+		}
+
+		if err := s.emailVerifier.Issue(tx, &user); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserRegistered,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email, "username": user.Username},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventUserRegistered,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return warnings, nil
+}
+
+// notifyExistingAccount tells the owner of an address that someone tried to
+// register with it, in place of refusing the registration.
+func (s *AuthService) notifyExistingAccount(existing *model.Users, meta httputil.RequestMeta) error {
+	existingID := ulidutil.MustFromBytes(existing.ID)
+	if err := s.emailService.SendAccountExistsEmail(s.db, existingID, existing.Email, existing.Username, existing.Locale); err != nil {
+		return err
 	}
-	s.emailVerificationTokenRepo.Create(emailVerificationTokenModel)
-	urlEncodedToken := URLEncodeToken(token)
 
-	s.emailService.SendVerifyEmail(params.Email, params.Username, urlEncodedToken)
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventRegistrationDuplicate,
+		UserID:  &existingID,
+		Request: meta,
+	})
 
 	return nil
 }
@@ -76,26 +170,114 @@ type LoginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func (s *AuthService) Login(params LoginParams, ip string, userAgent string) (LoginResponse, error) {
+func (s *AuthService) Login(params LoginParams, meta httputil.RequestMeta) (LoginResponse, error) {
+	defer s.enumerationPolicy.pad(time.Now())
+
 	user, err := s.userRepo.GetByEmail(params.Email)
 	if err != nil {
 		return LoginResponse{}, err
 	}
 
 	if user == nil {
+		if s.enumerationPolicy.Enabled {
+			s.passwordHasher.CompareDummy(params.Password)
+		}
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventLoginFailed,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "unknown_email"},
+		})
 		return LoginResponse{}, apperror.NewUnauthorized("Invalid credentials")
 	}
 
-	match := ComparePasswordAndHash(params.Password, user.PasswordHash)
+	locked, err := s.checkLockout(user, meta)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	if locked {
+		// Unknown addresses are never locked out, so saying an account is
+		// would reveal that it exists
+		if s.enumerationPolicy.Enabled {
+			s.passwordHasher.CompareDummy(params.Password)
+			return LoginResponse{}, apperror.NewUnauthorized("Invalid credentials")
+		}
+		return LoginResponse{}, apperror.NewTooManyRequests("Too many failed login attempts, try again later")
+	}
+
+	match := s.passwordHasher.Compare(params.Password, user.PasswordHash)
 	if !match {
+		s.recordFailedLogin(user, meta)
+
+		userID := ulidutil.MustFromBytes(user.ID)
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventLoginFailed,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "invalid_password"},
+		})
 		return LoginResponse{}, apperror.NewUnauthorized("Invalid credentials")
 	}
 
+	// Only reveal that the account is disabled to someone who knows the password
+	if !user.Active {
+		userID := ulidutil.MustFromBytes(user.ID)
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventLoginFailed,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "inactive"},
+		})
+		return LoginResponse{}, apperror.NewForbidden("Account is disabled")
+	}
+
+	// Logging in doesn't cancel a deletion, the emailed link does
+	if user.DeleteAfter != nil {
+		userID := ulidutil.MustFromBytes(user.ID)
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventLoginFailed,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "deletion_scheduled"},
+		})
+		return LoginResponse{}, apperror.NewForbidden("Account is scheduled for deletion")
+	}
+
+	scope, err := s.emailVerifier.Scope(user)
+	if err != nil {
+		userID := ulidutil.MustFromBytes(user.ID)
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventLoginFailed,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "email_unverified"},
+		})
+		return LoginResponse{}, err
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ClearLockout(ulidutil.MustFromBytes(user.ID)); err != nil {
+			return LoginResponse{}, err
+		}
+	}
+
+	// The plaintext is only available here, so this is the one chance to move
+	// legacy and outdated hashes onto the current parameters
+	if s.passwordHasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(user, params.Password)
+	}
+
+	authentication := AuthenticationClaims{
+		AuthTime: jwt.NewNumericDate(time.Now()),
+		AMR:      []string{AMRPassword},
+	}
 	accessToken, err := GenerateAccessToken(GenerateAccessTokenParams{
-		privateKey: s.jwtAccessKey,
-		issuer:     s.issuer,
-		userID:     ulidutil.MustFromBytes(user.ID),
-		expiry:     s.accessTokenExpiry,
+		privateKey:    s.jwtAccessKey,
+		issuer:        s.issuer,
+		userID:        ulidutil.MustFromBytes(user.ID),
+		emailVerified: user.EmailVerified,
+		scope:         scope,
+		auth:          authentication,
+		expiry:        s.accessTokenExpiry,
 	})
 	if err != nil {
 		return LoginResponse{}, apperror.NewInternalServerError("Token generation error")
@@ -110,8 +292,8 @@ func (s *AuthService) Login(params LoginParams, ip string, userAgent string) (Lo
 		// TODO refactor this so we don't have the magic number everywhere
 		ExpiresAt: time.Now().Add(time.Duration(168) * time.Hour),
 		RevokedAt: nil,
-		IPAddress: ip,
-		UserAgent: userAgent,
+		IPAddress: meta.IP,
+		UserAgent: meta.UserAgent,
 	}
 	if err := s.refreshTokenRepo.Create(refreshTokenModel); err != nil {
 		return LoginResponse{}, err
@@ -122,12 +304,21 @@ func (s *AuthService) Login(params LoginParams, ip string, userAgent string) (Lo
 		issuer:     s.issuer,
 		userID:     userID,
 		tokenID:    ulidutil.MustFromBytes(refreshTokenModel.ID),
+		auth:       authentication,
 		expiry:     s.refreshTokenExpiry,
 	})
 	if err != nil {
 		return LoginResponse{}, apperror.NewInternalServerError("Token generation error")
 	}
 
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventLoginSucceeded,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"session_id": ulidutil.ToPrefixed("session", ulidutil.MustFromBytes(refreshTokenModel.ID))},
+	})
+
 	response := LoginResponse{
 		AccessToken:  accessToken,
 		TokenType:    "Bearer",
@@ -149,8 +340,8 @@ type RefreshResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func (s *AuthService) Refresh(params RefreshParams, ip string, userAgent string) (RefreshResponse, error) {
-	_, claims, err := ValidateToken(s.jwtRefreshKey.Public().(ed25519.PublicKey), params.RefreshToken)
+func (s *AuthService) Refresh(params RefreshParams, meta httputil.RequestMeta) (RefreshResponse, error) {
+	claims, err := ValidateRefreshToken(s.jwtRefreshKey.Public().(ed25519.PublicKey), params.RefreshToken)
 	if err != nil {
 		return RefreshResponse{}, err
 	}
@@ -173,17 +364,31 @@ func (s *AuthService) Refresh(params RefreshParams, ip string, userAgent string)
 		return RefreshResponse{}, apperror.NewUnauthorized("Invalid token")
 	}
 
+	// The user is read again so that the new access token reflects a
+	// verification or a grace period ending since the last one
+	userID := ulidutil.MustFromBytes(refreshToken.UserID)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return RefreshResponse{}, err
+	}
+	scope, err := s.emailVerifier.Scope(user)
+	if err != nil {
+		return RefreshResponse{}, err
+	}
+
 	refreshTokenULID := ulidutil.MustFromBytes(refreshToken.ID)
 	if err := s.refreshTokenRepo.Revoke(refreshTokenULID); err != nil {
 		return RefreshResponse{}, err
 	}
 
-	userID := ulidutil.MustFromBytes(refreshToken.UserID)
 	accessToken, err := GenerateAccessToken(GenerateAccessTokenParams{
-		privateKey: s.jwtAccessKey,
-		issuer:     s.issuer,
-		userID:     userID,
-		expiry:     s.accessTokenExpiry,
+		privateKey:    s.jwtAccessKey,
+		issuer:        s.issuer,
+		userID:        userID,
+		emailVerified: user.EmailVerified,
+		scope:         scope,
+		auth:          claims.AuthenticationClaims,
+		expiry:        s.accessTokenExpiry,
 	})
 	if err != nil {
 		return RefreshResponse{}, apperror.NewInternalServerError("Token generation error")
@@ -198,8 +403,8 @@ func (s *AuthService) Refresh(params RefreshParams, ip string, userAgent string)
 		// TODO refactor this so we don't have the magic number everywhere
 		ExpiresAt: time.Now().Add(time.Duration(168) * time.Hour),
 		RevokedAt: nil,
-		IPAddress: ip,
-		UserAgent: userAgent,
+		IPAddress: meta.IP,
+		UserAgent: meta.UserAgent,
 	}
 	if err := s.refreshTokenRepo.Create(newRefreshTokenModel); err != nil {
 		return RefreshResponse{}, err
@@ -210,6 +415,7 @@ func (s *AuthService) Refresh(params RefreshParams, ip string, userAgent string)
 		issuer:     s.issuer,
 		userID:     userID,
 		tokenID:    ulidutil.MustFromBytes(newRefreshTokenModel.ID),
+		auth:       claims.AuthenticationClaims,
 		expiry:     s.refreshTokenExpiry,
 	})
 
@@ -217,6 +423,17 @@ func (s *AuthService) Refresh(params RefreshParams, ip string, userAgent string)
 		return RefreshResponse{}, apperror.NewInternalServerError("Token generation error")
 	}
 
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventTokenRefreshed,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+		Metadata: map[string]any{
+			"session_id":        ulidutil.ToPrefixed("session", newRefreshTokenID),
+			"parent_session_id": ulidutil.ToPrefixed("session", refreshTokenULID),
+		},
+	})
+
 	return RefreshResponse{
 		AccessToken:  accessToken,
 		TokenType:    "Bearer",
@@ -229,28 +446,56 @@ type ForgotPasswordParams struct {
 	Email string `json:"email"`
 }
 
-func (s *AuthService) ForgotPassword(params ForgotPasswordParams) error {
+func (s *AuthService) ForgotPassword(params ForgotPasswordParams, meta httputil.RequestMeta) error {
+	defer s.enumerationPolicy.pad(time.Now())
+
 	user, err := s.userRepo.GetByEmail(params.Email)
 	if err != nil {
 		return err
 	}
-	userID := ulidutil.MustFromBytes(user.ID)
 
-	s.passwordResetTokenRepo.RevokeByUserID(userID)
+	// Answered like a known address so that it can't be used to find accounts
+	if user == nil {
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventPasswordResetRequested,
+			Request:  meta,
+			Metadata: map[string]any{"email": params.Email, "reason": "unknown_email"},
+		})
+		return nil
+	}
+	userID := ulidutil.MustFromBytes(user.ID)
 
 	token, hashedToken := GenerateResetToken()
-	passwordResetTokenModel := model.PasswordResetTokens{
-		ID:        ulid.Make().Bytes(),
-		UserID:    user.ID,
-		TokenHash: hashedToken,
-		ExpiresAt: time.Now().Add(time.Duration(15) * time.Minute),
-		RevokedAt: nil,
-		CreatedAt: time.Now(),
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		passwordResetTokenRepo := repositories.NewPasswordResetTokenRepository(tx)
+		if err := passwordResetTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		passwordResetTokenModel := model.PasswordResetTokens{
+			ID:        ulid.Make().Bytes(),
+			UserID:    user.ID,
+			TokenHash: hashedToken,
+			ExpiresAt: time.Now().Add(time.Duration(15) * time.Minute),
+			RevokedAt: nil,
+			CreatedAt: time.Now(),
+		}
+		if err := passwordResetTokenRepo.Create(passwordResetTokenModel); err != nil {
+			return err
+		}
+
+		urlEncodedToken := URLEncodeToken(token)
+		return s.emailService.SendForgotPasswordEmail(tx, userID, user.Email, user.Username, user.Locale, urlEncodedToken)
+	})
+	if err != nil {
+		return err
 	}
-	s.passwordResetTokenRepo.Create(passwordResetTokenModel)
-	urlEncodedToken := URLEncodeToken(token)
 
-	s.emailService.SendForgotPasswordEmail(params.Email, user.Username, urlEncodedToken)
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventPasswordResetRequested,
+		UserID:  &userID,
+		Request: meta,
+	})
 
 	return nil
 }
@@ -260,42 +505,112 @@ type PasswordResetParams struct {
 	NewPassword string `json:"new_password"`
 }
 
-func (s *AuthService) PasswordReset(params PasswordResetParams) error {
+func (s *AuthService) PasswordReset(params PasswordResetParams, meta httputil.RequestMeta) ([]apperror.Violation, error) {
 	token, err := URLDecodeToken(params.Token)
 	if err != nil {
-		return apperror.NewBadRequest("Invalid token")
+		return nil, apperror.NewBadRequest("Invalid token")
 	}
 
 	hashedToken := HashToken(token)
 	passwordResetToken, err := s.passwordResetTokenRepo.GetByHash(hashedToken)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	tokenID := ulidutil.MustFromBytes(passwordResetToken.ID)
-	if err := s.passwordResetTokenRepo.Revoke(tokenID); err != nil {
-		return err
+	if passwordResetToken.RevokedAt != nil {
+		return nil, apperror.NewBadRequest("Invalid token")
+	}
+
+	if passwordResetToken.ExpiresAt.Before(time.Now()) {
+		return nil, apperror.NewBadRequest("Invalid token")
 	}
 
 	userID := ulidutil.MustFromBytes(passwordResetToken.UserID)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
 
-	hashedPassword, err := HashPassword(params.NewPassword)
+	// Validate before spending the token so the user can try another password
+	warnings, err := s.passwordPolicy.Validate(passwordpolicy.Candidate{
+		Password: params.NewPassword,
+		Username: user.Username,
+		Email:    user.Email,
+		User:     user,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := s.userRepo.SetPassword(userID, hashedPassword); err != nil {
-		return err
+	hashedPassword, err := s.passwordHasher.Hash(params.NewPassword)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	tokenID := ulidutil.MustFromBytes(passwordResetToken.ID)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		passwordResetTokenRepo := repositories.NewPasswordResetTokenRepository(tx)
+		revoked, err := passwordResetTokenRepo.Revoke(tokenID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return apperror.NewBadRequest("Invalid token")
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.SetPassword(userID, hashedPassword); err != nil {
+			return err
+		}
+
+		if err := s.passwordPolicy.Remember(tx, userID, hashedPassword); err != nil {
+			return err
+		}
+
+		// Proving control of the mailbox is enough to lift a lockout
+		if err := userRepo.ClearLockout(userID); err != nil {
+			return err
+		}
+		accountUnlockTokenRepo := repositories.NewAccountUnlockTokenRepository(tx)
+		if err := accountUnlockTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		// Whoever knew the old password may still be signed in
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		if err := refreshTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		if err := s.emailService.SendPasswordChangedEmail(tx, userID, user.Email, user.Username, user.Locale); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserPasswordReset,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventPasswordReset,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return warnings, nil
 }
 
 type VerifyEmailParams struct {
 	Token string `json:"token"`
 }
 
-func (s *AuthService) VerifyEmail(params VerifyEmailParams) error {
+func (s *AuthService) VerifyEmail(params VerifyEmailParams, meta httputil.RequestMeta) error {
 	token, err := URLDecodeToken(params.Token)
 	if err != nil {
 		return apperror.NewBadRequest("Invalid token")
@@ -316,15 +631,125 @@ func (s *AuthService) VerifyEmail(params VerifyEmailParams) error {
 		return apperror.NewBadRequest("Invalid token")
 	}
 
+	userID := ulidutil.MustFromBytes(verificationToken.UserID)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
 	tokenID := ulidutil.MustFromBytes(verificationToken.ID)
-	if err := s.emailVerificationTokenRepo.Revoke(tokenID); err != nil {
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		emailVerificationTokenRepo := repositories.NewEmailVerificationTokenRepository(tx)
+		if err := emailVerificationTokenRepo.Revoke(tokenID); err != nil {
+			return err
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.SetEmailVerified(userID); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserEmailVerified,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email},
+		})
+	})
+	if err != nil {
 		return err
 	}
 
-	userID := ulidutil.MustFromBytes(verificationToken.UserID)
-	if err := s.userRepo.SetEmailVerified(userID); err != nil {
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventEmailVerified,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return nil
+}
+
+type ResendVerificationParams struct {
+	Email string `json:"email"`
+}
+
+// ResendVerification sends a new verification link to an unverified
+// account. It succeeds without sending anything when there is no such
+// account, it is already verified or it was sent a link too recently, so
+// that it reveals nothing about the address.
+func (s *AuthService) ResendVerification(params ResendVerificationParams, meta httputil.RequestMeta) error {
+	user, err := s.userRepo.GetByEmail(params.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailVerified {
+		return nil
+	}
+
+	retryAt, err := s.emailVerifier.Resend(user)
+	if err != nil {
 		return err
 	}
+	if !retryAt.IsZero() {
+		return nil
+	}
+
+	userID := ulidutil.MustFromBytes(user.ID)
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventVerificationEmailSent,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return nil
+}
+
+type UnlockAccountParams struct {
+	Token string `json:"token"`
+}
+
+func (s *AuthService) UnlockAccount(params UnlockAccountParams, meta httputil.RequestMeta) error {
+	token, err := URLDecodeToken(params.Token)
+	if err != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	hashedToken := HashToken(token)
+
+	unlockToken, err := s.accountUnlockTokenRepo.GetByHash(hashedToken)
+	if err != nil {
+		return err
+	}
+
+	if unlockToken.RevokedAt != nil {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	if unlockToken.ExpiresAt.Before(time.Now()) {
+		return apperror.NewBadRequest("Invalid token")
+	}
+
+	tokenID := ulidutil.MustFromBytes(unlockToken.ID)
+	userID := ulidutil.MustFromBytes(unlockToken.UserID)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		accountUnlockTokenRepo := repositories.NewAccountUnlockTokenRepository(tx)
+		if err := accountUnlockTokenRepo.Revoke(tokenID); err != nil {
+			return err
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		return userRepo.ClearLockout(userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventAccountUnlocked,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
 
 	return nil
 }
@@ -9,48 +9,111 @@ import (
 	"github.com/oklog/ulid/v2"
 )
 
+// ScopeVerifyEmail limits an access token to the endpoints a user needs to
+// verify their email address, see VerificationRestricted.
+const ScopeVerifyEmail = "verify_email"
+
+// AMRPassword is the authentication method reference for a password, as
+// registered in RFC 8176.
+const AMRPassword = "pwd"
+
+// AuthenticationClaims record when and how the user last proved who they are.
+// Refreshing carries them over, so they describe the login or
+// reauthentication rather than the token.
+type AuthenticationClaims struct {
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+}
+
+// AuthenticatedWithin reports whether the user proved who they are in the
+// last maxAge. Tokens issued before auth_time was recorded never are.
+func (c AuthenticationClaims) AuthenticatedWithin(maxAge time.Duration) bool {
+	return c.AuthTime != nil && time.Since(c.AuthTime.Time) <= maxAge
+}
+
+// AccessClaims are the claims of an access token.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	AuthenticationClaims
+	EmailVerified bool `json:"email_verified"`
+	// Empty for a token with full access
+	Scope string `json:"scope,omitempty"`
+}
+
 type GenerateAccessTokenParams struct {
-	privateKey ed25519.PrivateKey
-	issuer     string
-	userID     ulid.ULID
-	expiry     time.Duration
+	privateKey    ed25519.PrivateKey
+	issuer        string
+	userID        ulid.ULID
+	emailVerified bool
+	scope         string
+	auth          AuthenticationClaims
+	expiry        time.Duration
 }
 
 func GenerateAccessToken(params GenerateAccessTokenParams) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   params.userID.String(),
-		Issuer:    params.issuer,
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(params.expiry)),
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   params.userID.String(),
+			Issuer:    params.issuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(params.expiry)),
+		},
+		AuthenticationClaims: params.auth,
+		EmailVerified:        params.emailVerified,
+		Scope:                params.scope,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
 	return token.SignedString(params.privateKey)
 }
 
+// RefreshClaims are the claims of a refresh token.
+type RefreshClaims struct {
+	jwt.RegisteredClaims
+	AuthenticationClaims
+}
+
 type GenerateRefreshTokenParams struct {
 	privateKey ed25519.PrivateKey
 	issuer     string
 	userID     ulid.ULID
 	tokenID    ulid.ULID
+	auth       AuthenticationClaims
 	expiry     time.Duration
 }
 
 func GenerateRefreshToken(params GenerateRefreshTokenParams) (string, error) {
-	claims := jwt.RegisteredClaims{
-		ID:        params.tokenID.String(),
-		Subject:   params.userID.String(),
-		Issuer:    params.issuer,
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(params.expiry)),
+	claims := RefreshClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        params.tokenID.String(),
+			Subject:   params.userID.String(),
+			Issuer:    params.issuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(params.expiry)),
+		},
+		AuthenticationClaims: params.auth,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
 	return token.SignedString(params.privateKey)
 }
 
-func ValidateToken(publicKey ed25519.PublicKey, token string) (*jwt.Token, *jwt.RegisteredClaims, error) {
-	claims := &jwt.RegisteredClaims{}
+func ValidateRefreshToken(publicKey ed25519.PublicKey, token string) (*RefreshClaims, error) {
+	claims := &RefreshClaims{}
+	verifiedToken, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, apperror.NewUnauthorized("Invalid token")
+		}
+		return publicKey, nil
+	})
+	if err != nil || !verifiedToken.Valid {
+		return nil, apperror.NewUnauthorized("Invalid token")
+	}
+	return claims, nil
+}
+
+func ValidateAccessToken(publicKey ed25519.PublicKey, token string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
 	verifiedToken, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
 			return nil, apperror.NewUnauthorized("Invalid token")
@@ -58,9 +121,9 @@ func ValidateToken(publicKey ed25519.PublicKey, token string) (*jwt.Token, *jwt.
 		return publicKey, nil
 	})
 	if err != nil || !verifiedToken.Valid {
-		return nil, nil, apperror.NewUnauthorized("Invalid token")
+		return nil, apperror.NewUnauthorized("Invalid token")
 	}
-	return verifiedToken, claims, nil
+	return claims, nil
 }
 
 func ValidateClaims(claims *jwt.RegisteredClaims, issuer string) error {
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"auth/internal/audit"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// LockoutPolicy protects individual accounts from password guessing spread
+// across many IPs. After BackoffAfter consecutive failures each further attempt
+// must wait BackoffBase, doubling per failure, and after Threshold failures
+// the account is locked for Duration.
+type LockoutPolicy struct {
+	BackoffAfter int
+	BackoffBase  time.Duration
+	Threshold    int
+	Duration     time.Duration
+}
+
+// retryAt returns when the user may next attempt to log in, or the zero time
+// if they may try now.
+func (p LockoutPolicy) retryAt(user *model.Users) time.Time {
+	if user.LockedUntil != nil {
+		return *user.LockedUntil
+	}
+
+	failures := int(user.FailedLoginAttempts)
+	if p.BackoffBase <= 0 || user.LastFailedLoginAt == nil || failures < p.BackoffAfter {
+		return time.Time{}
+	}
+
+	delay := p.BackoffBase << min(failures-p.BackoffAfter, 20)
+	if p.Duration > 0 {
+		delay = min(delay, p.Duration)
+	}
+	return user.LastFailedLoginAt.Add(delay)
+}
+
+// checkLockout reports whether the attempt must be rejected because the
+// account is locked or backing off. Expired locks are cleared so the user
+// starts over with a clean counter.
+func (s *AuthService) checkLockout(user *model.Users, meta httputil.RequestMeta) (bool, error) {
+	userID := ulidutil.MustFromBytes(user.ID)
+
+	if user.LockedUntil != nil && !user.LockedUntil.After(time.Now()) {
+		if err := s.userRepo.ClearLockout(userID); err != nil {
+			return false, err
+		}
+		user.FailedLoginAttempts = 0
+		user.LastFailedLoginAt = nil
+		user.LockedUntil = nil
+	}
+
+	retryAt := s.lockoutPolicy.retryAt(user)
+	if retryAt.IsZero() || !retryAt.After(time.Now()) {
+		return false, nil
+	}
+
+	reason := "backoff"
+	if user.LockedUntil != nil {
+		reason = "locked"
+	}
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventLoginFailed,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"email": user.Email, "reason": reason, "retry_at": retryAt},
+	})
+
+	return true, nil
+}
+
+// recordFailedLogin counts a failed password check and locks the account once
+// the threshold is reached, emailing the user a link to unlock it.
+func (s *AuthService) recordFailedLogin(user *model.Users, meta httputil.RequestMeta) {
+	userID := ulidutil.MustFromBytes(user.ID)
+
+	updated, err := s.userRepo.RecordFailedLogin(userID)
+	if err != nil {
+		return
+	}
+
+	if s.lockoutPolicy.Threshold <= 0 || int(updated.FailedLoginAttempts) < s.lockoutPolicy.Threshold || updated.LockedUntil != nil {
+		return
+	}
+
+	lockedUntil := time.Now().Add(s.lockoutPolicy.Duration)
+	token, hashedToken := GenerateResetToken()
+
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.Lock(userID, lockedUntil); err != nil {
+			return err
+		}
+
+		accountUnlockTokenRepo := repositories.NewAccountUnlockTokenRepository(tx)
+		if err := accountUnlockTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		accountUnlockTokenModel := model.AccountUnlockTokens{
+			ID:        ulid.Make().Bytes(),
+			UserID:    user.ID,
+			TokenHash: hashedToken,
+			ExpiresAt: lockedUntil,
+			RevokedAt: nil,
+			CreatedAt: time.Now(),
+		}
+		if err := accountUnlockTokenRepo.Create(accountUnlockTokenModel); err != nil {
+			return err
+		}
+
+		urlEncodedToken := URLEncodeToken(token)
+		return s.emailService.SendAccountLockedEmail(tx, userID, user.Email, user.Username, user.Locale, urlEncodedToken, lockedUntil)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to lock account: %v", err)
+		return
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventAccountLocked,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"failed_login_attempts": updated.FailedLoginAttempts, "locked_until": lockedUntil},
+	})
+}
@@ -1,23 +1,23 @@
 package auth
 
 import (
-	"auth/internal/apperror"
-
-	"github.com/alexedwards/argon2id"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/ulidutil"
+	"log"
 )
 
-func HashPassword(password string) (string, error) {
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+// rehashPassword replaces a user's stored hash with one using the current
+// parameters. Failure leaves the old hash in place, which still verifies, so
+// it is logged rather than failing the login.
+func (s *AuthService) rehashPassword(user *model.Users, password string) {
+	hash, err := s.passwordHasher.Hash(password)
 	if err != nil {
-		return "", apperror.NewInternalServerError("Internal server error")
+		log.Printf("[ERROR] Failed to rehash password: %v", err)
+		return
 	}
-	return string(hash), nil
-}
 
-func ComparePasswordAndHash(password string, hash string) bool {
-	match, err := argon2id.ComparePasswordAndHash(password, hash)
-	if err != nil {
-		return false
+	if err := s.userRepo.SetPassword(ulidutil.MustFromBytes(user.ID), hash); err != nil {
+		return
 	}
-	return match
+	user.PasswordHash = hash
 }
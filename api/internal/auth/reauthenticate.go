@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/httputil"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+type ReauthenticateParams struct {
+	Password string `json:"password"`
+}
+
+type ReauthenticateResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Reauthenticate checks the password of the user an access token belongs to
+// and returns an access token elevated for sensitive operations. It only lasts
+// as long as the elevation, after which the client goes back to refreshing
+// the session as usual. Failures count towards the account lockout like
+// failed logins.
+func (s *AuthService) Reauthenticate(claims *AccessClaims, params ReauthenticateParams, meta httputil.RequestMeta) (ReauthenticateResponse, error) {
+	userID, err := ulid.Parse(claims.Subject)
+	if err != nil {
+		return ReauthenticateResponse{}, apperror.NewUnauthorized("Invalid token")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return ReauthenticateResponse{}, err
+	}
+	if !user.Active {
+		return ReauthenticateResponse{}, apperror.NewForbidden("Account is disabled")
+	}
+	if user.DeleteAfter != nil {
+		return ReauthenticateResponse{}, apperror.NewForbidden("Account is scheduled for deletion")
+	}
+
+	locked, err := s.checkLockout(user, meta)
+	if err != nil {
+		return ReauthenticateResponse{}, err
+	}
+	if locked {
+		return ReauthenticateResponse{}, apperror.NewTooManyRequests("Too many failed login attempts, try again later")
+	}
+
+	if !s.passwordHasher.Compare(params.Password, user.PasswordHash) {
+		s.recordFailedLogin(user, meta)
+
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventReauthenticationFailed,
+			ActorID:  &userID,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"reason": "invalid_password"},
+		})
+		return ReauthenticateResponse{}, apperror.NewUnauthorized("Invalid credentials")
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ClearLockout(userID); err != nil {
+			return ReauthenticateResponse{}, err
+		}
+	}
+
+	scope, err := s.emailVerifier.Scope(user)
+	if err != nil {
+		return ReauthenticateResponse{}, err
+	}
+
+	accessToken, err := GenerateAccessToken(GenerateAccessTokenParams{
+		privateKey:    s.jwtAccessKey,
+		issuer:        s.issuer,
+		userID:        userID,
+		emailVerified: user.EmailVerified,
+		scope:         scope,
+		auth: AuthenticationClaims{
+			AuthTime: jwt.NewNumericDate(time.Now()),
+			AMR:      []string{AMRPassword},
+		},
+		expiry: s.reauthMaxAge,
+	})
+	if err != nil {
+		return ReauthenticateResponse{}, apperror.NewInternalServerError("Token generation error")
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventReauthenticated,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return ReauthenticateResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.reauthMaxAge.Seconds()),
+	}, nil
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"auth/internal/apperror"
 	"auth/internal/httputil"
-	"net"
+	"auth/internal/passwordpolicy"
+	"crypto/ed25519"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -11,36 +14,31 @@ import (
 func Router(s *AuthService) http.Handler {
 	r := chi.NewRouter()
 
-	r.Post("/register", func(w http.ResponseWriter, r *http.Request) {
+	r.With(s.rateLimiter.Middleware("register")).Post("/register", func(w http.ResponseWriter, r *http.Request) {
 		var body CreateUserParams
 		if err := httputil.ParseBody(w, r, &body); err != nil {
 			return
 		}
 
-		err := s.CreateUser(body)
+		warnings, err := s.CreateUser(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
 		}
+		if len(warnings) > 0 {
+			httputil.JSONResponse(w, http.StatusOK, passwordpolicy.WarningsResponse{Warnings: warnings})
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 	})
 
-	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+	r.With(s.rateLimiter.Middleware("login")).Post("/login", func(w http.ResponseWriter, r *http.Request) {
 		var body LoginParams
 		if err := httputil.ParseBody(w, r, &body); err != nil {
 			return
 		}
 
-		ip := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			ip = forwarded
-		}
-		if host, _, err := net.SplitHostPort(ip); err == nil {
-			ip = host
-		}
-		userAgent := r.UserAgent()
-
-		loginResponse, err := s.Login(body, ip, userAgent)
+		loginResponse, err := s.Login(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
@@ -55,16 +53,7 @@ func Router(s *AuthService) http.Handler {
 			return
 		}
 
-		ip := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			ip = forwarded
-		}
-		if host, _, err := net.SplitHostPort(ip); err == nil {
-			ip = host
-		}
-		userAgent := r.UserAgent()
-
-		refreshResponse, err := s.Refresh(body, ip, userAgent)
+		refreshResponse, err := s.Refresh(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
@@ -73,13 +62,13 @@ func Router(s *AuthService) http.Handler {
 		httputil.JSONResponse(w, http.StatusOK, refreshResponse)
 	})
 
-	r.Post("/forgot-password", func(w http.ResponseWriter, r *http.Request) {
+	r.With(s.rateLimiter.Middleware("forgot-password")).Post("/forgot-password", func(w http.ResponseWriter, r *http.Request) {
 		var body ForgotPasswordParams
 		if err := httputil.ParseBody(w, r, &body); err != nil {
 			return
 		}
 
-		err := s.ForgotPassword(body)
+		err := s.ForgotPassword(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
@@ -89,29 +78,46 @@ func Router(s *AuthService) http.Handler {
 	})
 
 	r.Post("/password-reset", func(w http.ResponseWriter, r *http.Request) {
-		println("here")
-
 		var body PasswordResetParams
 		if err := httputil.ParseBody(w, r, &body); err != nil {
 			return
 		}
 
-		err := s.PasswordReset(body)
+		warnings, err := s.PasswordReset(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
 		}
+		if len(warnings) > 0 {
+			httputil.JSONResponse(w, http.StatusOK, passwordpolicy.WarningsResponse{Warnings: warnings})
+			return
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 	})
 
-	r.Post("/verify-email", func(w http.ResponseWriter, r *http.Request) {
+	r.With(s.rateLimiter.Middleware("verify-email")).Post("/verify-email", func(w http.ResponseWriter, r *http.Request) {
 		var body VerifyEmailParams
 		if err := httputil.ParseBody(w, r, &body); err != nil {
 			return
 		}
 
-		err := s.VerifyEmail(body)
+		err := s.VerifyEmail(body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.With(s.rateLimiter.Middleware("resend-verification")).Post("/resend-verification", func(w http.ResponseWriter, r *http.Request) {
+		var body ResendVerificationParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		err := s.ResendVerification(body, httputil.GetRequestMeta(r))
 		if err != nil {
 			httputil.HandleError(w, err)
 			return
@@ -120,5 +126,110 @@ func Router(s *AuthService) http.Handler {
 		w.WriteHeader(http.StatusNoContent)
 	})
 
+	r.With(s.rateLimiter.Middleware("email-change")).Post("/confirm-email-change", func(w http.ResponseWriter, r *http.Request) {
+		var body ConfirmEmailChangeParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		err := s.ConfirmEmailChange(body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.With(s.rateLimiter.Middleware("email-change")).Post("/cancel-email-change", func(w http.ResponseWriter, r *http.Request) {
+		var body CancelEmailChangeParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		err := s.CancelEmailChange(body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.Post("/unlock-account", func(w http.ResponseWriter, r *http.Request) {
+		var body UnlockAccountParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		err := s.UnlockAccount(body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.Post("/cancel-deletion", func(w http.ResponseWriter, r *http.Request) {
+		var body CancelDeletionParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		err := s.CancelDeletion(body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r.With(s.rateLimiter.Middleware("reauthenticate")).Post("/reauthenticate", func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.bearerClaims(r)
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		var body ReauthenticateParams
+		if err := httputil.ParseBody(w, r, &body); err != nil {
+			return
+		}
+
+		response, err := s.Reauthenticate(claims, body, httputil.GetRequestMeta(r))
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, response)
+	})
+
 	return r
 }
+
+// bearerClaims authenticates a request by its access token like
+// middleware.Auth, which can't be used here as it imports this package.
+// Tokens restricted to ScopeVerifyEmail are accepted.
+func (s *AuthService) bearerClaims(r *http.Request) (*AccessClaims, error) {
+	authHeader := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	if authHeader == "" || token == authHeader {
+		return nil, apperror.NewUnauthorized("")
+	}
+
+	claims, err := ValidateAccessToken(s.jwtAccessKey.Public().(ed25519.PublicKey), token)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateClaims(&claims.RegisteredClaims, s.issuer); err != nil {
+		return nil, err
+	}
+	if claims.Scope != "" && claims.Scope != ScopeVerifyEmail {
+		return nil, apperror.NewUnauthorized("Invalid token")
+	}
+
+	return claims, nil
+}
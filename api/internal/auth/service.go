@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"auth/internal/audit"
 	"auth/internal/emails"
+	"auth/internal/passwordhash"
+	"auth/internal/passwordpolicy"
+	"auth/internal/ratelimit"
 	"auth/internal/repositories"
+	"auth/internal/webhooks"
 	"crypto/ed25519"
 	"database/sql"
 	"time"
@@ -16,14 +21,27 @@ type AuthService struct {
 	accessTokenExpiry  time.Duration
 	refreshTokenExpiry time.Duration
 	emailService       *emails.EmailService
+	emailVerifier      *EmailVerifier
+	auditLogger        *audit.Logger
+	webhookDispatcher  *webhooks.Dispatcher
+	rateLimiter        *ratelimit.Limiter
+	lockoutPolicy      LockoutPolicy
+	enumerationPolicy  EnumerationPolicy
+	reauthMaxAge       time.Duration
+	passwordHasher     *passwordhash.Hasher
+	passwordPolicy     *passwordpolicy.Policy
 
 	userRepo                   repositories.UserRepository
 	refreshTokenRepo           repositories.RefreshTokenRepository
 	passwordResetTokenRepo     repositories.PasswordResetTokenRepository
 	emailVerificationTokenRepo repositories.EmailVerificationTokenRepository
+	accountUnlockTokenRepo     repositories.AccountUnlockTokenRepository
+	emailChangeRequestRepo     repositories.EmailChangeRequestRepository
+	accountDeletionTokenRepo   repositories.AccountDeletionTokenRepository
+	accountTombstoneRepo       repositories.AccountTombstoneRepository
 }
 
-func NewAuthService(db *sql.DB, accessKey ed25519.PrivateKey, refreshKey ed25519.PrivateKey, issuer string, emailService *emails.EmailService) (*AuthService, error) {
+func NewAuthService(db *sql.DB, accessKey ed25519.PrivateKey, refreshKey ed25519.PrivateKey, issuer string, emailService *emails.EmailService, emailVerifier *EmailVerifier, auditLogger *audit.Logger, webhookDispatcher *webhooks.Dispatcher, rateLimiter *ratelimit.Limiter, lockoutPolicy LockoutPolicy, enumerationPolicy EnumerationPolicy, reauthMaxAge time.Duration, passwordHasher *passwordhash.Hasher, passwordPolicy *passwordpolicy.Policy) (*AuthService, error) {
 	return &AuthService{
 		db:                         db,
 		jwtAccessKey:               accessKey,
@@ -32,9 +50,22 @@ func NewAuthService(db *sql.DB, accessKey ed25519.PrivateKey, refreshKey ed25519
 		accessTokenExpiry:          15 * time.Minute,
 		refreshTokenExpiry:         168 * time.Hour, // 7 days
 		emailService:               emailService,
+		emailVerifier:              emailVerifier,
+		auditLogger:                auditLogger,
+		webhookDispatcher:          webhookDispatcher,
+		rateLimiter:                rateLimiter,
+		lockoutPolicy:              lockoutPolicy,
+		enumerationPolicy:          enumerationPolicy,
+		reauthMaxAge:               reauthMaxAge,
+		passwordHasher:             passwordHasher,
+		passwordPolicy:             passwordPolicy,
 		userRepo:                   repositories.NewUserRepository(db),
 		refreshTokenRepo:           repositories.NewRefreshTokenRepository(db),
 		passwordResetTokenRepo:     repositories.NewPasswordResetTokenRepository(db),
 		emailVerificationTokenRepo: repositories.NewEmailVerificationTokenRepository(db),
+		accountUnlockTokenRepo:     repositories.NewAccountUnlockTokenRepository(db),
+		emailChangeRequestRepo:     repositories.NewEmailChangeRequestRepository(db),
+		accountDeletionTokenRepo:   repositories.NewAccountDeletionTokenRepository(db),
+		accountTombstoneRepo:       repositories.NewAccountTombstoneRepository(db),
 	}, nil
 }
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/emails"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// VerificationPolicy decides what users who haven't verified their email
+// address may do.
+type VerificationPolicy string
+
+const (
+	// Unverified users have full access, the email_verified claim is left for
+	// downstream services to enforce
+	VerificationOptional VerificationPolicy = "optional"
+	// Unverified users can't log in
+	VerificationRequired VerificationPolicy = "required"
+	// Unverified users get tokens with ScopeVerifyEmail, which only allow
+	// viewing and correcting their profile and asking for a new link
+	VerificationRestricted VerificationPolicy = "restricted"
+	// Unverified users have full access for the grace period after
+	// registering, and can't log in after that
+	VerificationGracePeriod VerificationPolicy = "grace"
+)
+
+// VerificationConfig sets the VerificationPolicy and throttles verification
+// emails per account, so that asking for new links can't be used to flood
+// someone's inbox.
+type VerificationConfig struct {
+	Policy VerificationPolicy
+	// Only used by VerificationGracePeriod
+	GracePeriod time.Duration
+	// Minimum time between two links
+	ResendInterval time.Duration
+	// Most links sent in a rolling day, counting the one sent at registration
+	DailyLimit int
+}
+
+// EmailVerifier issues the links users verify their email address with.
+type EmailVerifier struct {
+	db           *sql.DB
+	config       VerificationConfig
+	emailService *emails.EmailService
+}
+
+func NewEmailVerifier(db *sql.DB, config VerificationConfig, emailService *emails.EmailService) *EmailVerifier {
+	return &EmailVerifier{
+		db:           db,
+		config:       config,
+		emailService: emailService,
+	}
+}
+
+// Issue revokes the user's outstanding links and sends a new one through db,
+// which should be the transaction the user was created or changed in. It is
+// not throttled.
+func (v *EmailVerifier) Issue(db repositories.DB, user *model.Users) error {
+	userID := ulidutil.MustFromBytes(user.ID)
+
+	emailVerificationTokenRepo := repositories.NewEmailVerificationTokenRepository(db)
+	if err := emailVerificationTokenRepo.RevokeByUserID(userID); err != nil {
+		return err
+	}
+
+	token, hashedToken := GenerateResetToken()
+	emailVerificationTokenModel := model.EmailVerificationTokens{
+		ID:        ulid.Make().Bytes(),
+		UserID:    user.ID,
+		TokenHash: hashedToken,
+		ExpiresAt: time.Now().Add(time.Duration(24) * time.Hour),
+		RevokedAt: nil,
+		CreatedAt: time.Now(),
+	}
+	if err := emailVerificationTokenRepo.Create(emailVerificationTokenModel); err != nil {
+		return err
+	}
+
+	urlEncodedToken := URLEncodeToken(token)
+	return v.emailService.SendVerifyEmail(db, userID, user.Email, user.Username, user.Locale, urlEncodedToken)
+}
+
+// Resend issues a new link unless the account has been sent too many too
+// recently, in which case it returns when the next one may be sent instead.
+// The user's row is locked while the throttle is checked, so that concurrent
+// requests can't both get through it.
+func (v *EmailVerifier) Resend(user *model.Users) (time.Time, error) {
+	userID := ulidutil.MustFromBytes(user.ID)
+
+	var retryAt time.Time
+	err := repositories.WithTx(v.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		user, err := userRepo.GetByIDForUpdate(userID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		emailVerificationTokenRepo := repositories.NewEmailVerificationTokenRepository(tx)
+		recent, err := emailVerificationTokenRepo.ListCreatedSince(userID, now.Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+
+		// recent is newest first
+		if len(recent) > 0 {
+			retryAt = recent[0].CreatedAt.Add(v.config.ResendInterval)
+		}
+		if v.config.DailyLimit > 0 && len(recent) >= v.config.DailyLimit {
+			if dayStartsOver := recent[v.config.DailyLimit-1].CreatedAt.Add(24 * time.Hour); dayStartsOver.After(retryAt) {
+				retryAt = dayStartsOver
+			}
+		}
+		if retryAt.After(now) {
+			return nil
+		}
+		retryAt = time.Time{}
+
+		return v.Issue(tx, user)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return retryAt, nil
+}
+
+// Scope returns the scope of the access tokens user may be issued under the
+// policy, or Forbidden if they may not be issued any.
+func (v *EmailVerifier) Scope(user *model.Users) (string, error) {
+	if user.EmailVerified {
+		return "", nil
+	}
+
+	switch v.config.Policy {
+	case VerificationRequired:
+		return "", apperror.NewForbidden("Email address not verified")
+	case VerificationRestricted:
+		return ScopeVerifyEmail, nil
+	case VerificationGracePeriod:
+		if time.Now().After(user.CreatedAt.Add(v.config.GracePeriod)) {
+			return "", apperror.NewForbidden("Email address not verified")
+		}
+	}
+	return "", nil
+}
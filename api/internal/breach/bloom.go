@@ -0,0 +1,213 @@
+package breach
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+var filterMagic = [8]byte{'H', 'I', 'B', 'P', 'B', 'L', 'M', '1'}
+
+type filterHeader struct {
+	Magic    [8]byte
+	M        uint64
+	K        uint32
+	MinCount uint32
+}
+
+// Filter is a bloom filter of breached password hashes. It can only answer
+// "probably breached" or "definitely not", so every hit is reported with the
+// minimum count the filter was built with.
+type Filter struct {
+	bits     []uint64
+	m        uint64
+	k        uint32
+	minCount uint32
+}
+
+func newFilter(n uint64, falsePositiveRate float64, minCount uint32) *Filter {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint32(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &Filter{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		minCount: minCount,
+	}
+}
+
+func LoadFilter(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach filter: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	var header filterHeader
+	if err := binary.Read(reader, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read breach filter header: %w", err)
+	}
+	if header.Magic != filterMagic || header.M == 0 || header.K == 0 {
+		return nil, errors.New("not a breach filter file")
+	}
+
+	filter := &Filter{
+		bits:     make([]uint64, (header.M+63)/64),
+		m:        header.M,
+		k:        header.K,
+		minCount: header.MinCount,
+	}
+	if err := binary.Read(reader, binary.BigEndian, filter.bits); err != nil {
+		return nil, fmt.Errorf("failed to read breach filter: %w", err)
+	}
+
+	return filter, nil
+}
+
+func (f *Filter) Count(password string) (int, error) {
+	sum, _ := hex.DecodeString(HashPassword(password))
+	if f.contains(sum) {
+		return int(f.minCount), nil
+	}
+	return 0, nil
+}
+
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	writer := bufio.NewWriter(w)
+	header := filterHeader{Magic: filterMagic, M: f.m, K: f.k, MinCount: f.minCount}
+
+	if err := binary.Write(writer, binary.BigEndian, header); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(writer, binary.BigEndian, f.bits); err != nil {
+		return 0, err
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.Size(header) + binary.Size(f.bits)), nil
+}
+
+// positions derives the k bits for a hash by double hashing. SHA-1 output is
+// already uniformly distributed, so its first two words serve as the seeds.
+func (f *Filter) positions(sum []byte, visit func(bit uint64) bool) {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	for i := uint64(0); i < uint64(f.k); i++ {
+		if !visit((h1 + i*h2) % f.m) {
+			return
+		}
+	}
+}
+
+func (f *Filter) add(sum []byte) {
+	f.positions(sum, func(bit uint64) bool {
+		f.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+func (f *Filter) contains(sum []byte) bool {
+	found := true
+	f.positions(sum, func(bit uint64) bool {
+		found = f.bits[bit/64]&(1<<(bit%64)) != 0
+		return found
+	})
+	return found
+}
+
+type BuildStats struct {
+	Lines    uint64
+	Included uint64
+	Bytes    int64
+}
+
+// BuildFilter reads a HIBP hash:count file and writes a bloom filter holding
+// every hash seen at least minCount times. The input is read twice, once to
+// size the filter and once to fill it.
+func BuildFilter(inputPath string, outputPath string, minCount uint32, falsePositiveRate float64) (BuildStats, error) {
+	var stats BuildStats
+
+	err := scanHashFile(inputPath, minCount, func(sum []byte) {
+		stats.Lines++
+		if sum != nil {
+			stats.Included++
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	filter := newFilter(stats.Included, falsePositiveRate, minCount)
+	err = scanHashFile(inputPath, minCount, func(sum []byte) {
+		if sum != nil {
+			filter.add(sum)
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create breach filter: %w", err)
+	}
+	defer output.Close()
+
+	stats.Bytes, err = filter.WriteTo(output)
+	if err != nil {
+		return stats, fmt.Errorf("failed to write breach filter: %w", err)
+	}
+
+	return stats, output.Close()
+}
+
+// scanHashFile calls visit for every line, passing the decoded hash if the
+// line's count reaches minCount and nil otherwise.
+func scanHashFile(path string, minCount uint32, visit func(sum []byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open breach hash file: %w", err)
+	}
+	defer file.Close()
+
+	line := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		hash, count, err := parseLine(text)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if count < int(minCount) {
+			visit(nil)
+			continue
+		}
+
+		sum, err := hex.DecodeString(hash)
+		if err != nil {
+			return fmt.Errorf("line %d: malformed hash %q", line, hash)
+		}
+		visit(sum)
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,41 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Checker reports how often a password appears in a breach corpus.
+type Checker interface {
+	// Count returns the number of times the password was seen in breaches, or
+	// 0 if it was never seen.
+	Count(password string) (int, error)
+}
+
+// Open loads a breach corpus from path. A directory is read as HIBP range
+// files named by hash prefix, a file ending in .bloom as a filter built by
+// BuildFilter, and anything else as a sorted HIBP hash:count file.
+func Open(path string) (Checker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach corpus: %w", err)
+	}
+
+	switch {
+	case info.IsDir():
+		return NewRangeDirectory(path), nil
+	case strings.HasSuffix(path, ".bloom"):
+		return LoadFilter(path)
+	default:
+		return OpenHashFile(path)
+	}
+}
+
+// HashPassword returns the upper case hex SHA-1 that HIBP files are keyed by.
+func HashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
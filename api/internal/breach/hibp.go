@@ -0,0 +1,165 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashLength   = 40
+	prefixLength = 5
+	// Lines are a 40 character hash, a colon and a count, so any window this
+	// large starting mid-line contains the start of the next line
+	maxLineLength = 64
+)
+
+// HashFile searches a HIBP "HASH:COUNT" file sorted by hash, as produced by
+// the official downloader, without loading it into memory.
+type HashFile struct {
+	file *os.File
+	size int64
+}
+
+func OpenHashFile(path string) (*HashFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach hash file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat breach hash file: %w", err)
+	}
+
+	return &HashFile{file: file, size: info.Size()}, nil
+}
+
+func (f *HashFile) Close() error {
+	return f.file.Close()
+}
+
+// Count binary searches the file by byte offset. Each probe reads the first
+// line starting at or after the probed offset.
+func (f *HashFile) Count(password string) (int, error) {
+	target := HashPassword(password)
+
+	low, high := int64(0), f.size
+	for low < high {
+		mid := low + (high-low)/2
+		hash, count, next, err := f.lineAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if next < 0 {
+			high = mid
+			continue
+		}
+
+		switch cmp := strings.Compare(hash, target); {
+		case cmp == 0:
+			return count, nil
+		case cmp < 0:
+			low = next
+		default:
+			high = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// lineAfter parses the first line starting at or after offset. It returns
+// next < 0 if there is no such line.
+func (f *HashFile) lineAfter(offset int64) (hash string, count int, next int64, err error) {
+	if offset == 0 {
+		return f.lineAt(0)
+	}
+
+	buf := make([]byte, maxLineLength)
+	n, err := f.file.ReadAt(buf, offset-1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, -1, err
+	}
+
+	newline := bytes.IndexByte(buf[:n], '\n')
+	if newline < 0 || offset-1+int64(newline)+1 >= f.size {
+		return "", 0, -1, nil
+	}
+
+	return f.lineAt(offset - 1 + int64(newline) + 1)
+}
+
+// lineAt parses the line starting at offset and returns the offset of the
+// line after it.
+func (f *HashFile) lineAt(offset int64) (hash string, count int, next int64, err error) {
+	buf := make([]byte, maxLineLength)
+	n, err := f.file.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, -1, err
+	}
+
+	line := buf[:n]
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	next = offset + int64(len(line)) + 1
+
+	hash, count, err = parseLine(string(line))
+	return hash, count, next, err
+}
+
+// RangeDirectory reads a directory of HIBP range files, one per five
+// character hash prefix, each holding "SUFFIX:COUNT" lines.
+type RangeDirectory struct {
+	path string
+}
+
+func NewRangeDirectory(path string) *RangeDirectory {
+	return &RangeDirectory{path: path}
+}
+
+func (d *RangeDirectory) Count(password string) (int, error) {
+	hash := HashPassword(password)
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	file, err := os.Open(filepath.Join(d.path, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if found && strings.EqualFold(lineSuffix, suffix) {
+			return strconv.Atoi(count)
+		}
+	}
+
+	return 0, scanner.Err()
+}
+
+func parseLine(line string) (string, int, error) {
+	hash, countText, found := strings.Cut(strings.TrimSpace(line), ":")
+	if !found || len(hash) != hashLength {
+		return "", 0, fmt.Errorf("malformed breach hash line %q", line)
+	}
+
+	count, err := strconv.Atoi(countText)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed breach count in line %q", line)
+	}
+
+	return strings.ToUpper(hash), count, nil
+}
@@ -0,0 +1,66 @@
+package bulk
+
+import (
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"io"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const exportBatchSize = 1000
+
+type Exporter struct {
+	userRepo repositories.UserRepository
+}
+
+func NewExporter(db *sql.DB) *Exporter {
+	return &Exporter{
+		userRepo: repositories.NewUserRepository(db),
+	}
+}
+
+// Export writes every user to w in batches so the whole table is never held
+// in memory. The output can be fed back into Import unchanged.
+func (e *Exporter) Export(w io.Writer, format Format) (int, error) {
+	writer, err := newRecordWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var after *ulid.ULID
+	for {
+		users, err := e.userRepo.ListAfter(after, exportBatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		for _, user := range users {
+			record := Record{
+				ID:            ulidutil.ToPrefixed("user", ulidutil.MustFromBytes(user.ID)),
+				Username:      user.Username,
+				Email:         user.Email,
+				PasswordHash:  user.PasswordHash,
+				EmailVerified: user.EmailVerified,
+				CreatedAt:     &user.CreatedAt,
+				UpdatedAt:     &user.UpdatedAt,
+			}
+			if err := writer.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if err := writer.Flush(); err != nil {
+			return count, err
+		}
+		if len(users) < exportBatchSize {
+			return count, nil
+		}
+
+		last := ulidutil.MustFromBytes(users[len(users)-1].ID)
+		after = &last
+	}
+}
@@ -0,0 +1,251 @@
+package bulk
+
+import (
+	"auth/internal/apperror"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSONL, "ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", apperror.NewBadRequest("Unknown format, expected csv or jsonl")
+	}
+}
+
+// Record is one user as it appears in an import or export file. ID may be a
+// prefixed or bare ULID or a UUID, and is generated when empty.
+type Record struct {
+	ID            string     `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	PasswordHash  string     `json:"password_hash"`
+	EmailVerified bool       `json:"email_verified"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+var csvColumns = []string{"id", "username", "email", "password_hash", "email_verified", "created_at", "updated_at"}
+
+const maxLineBytes = 1 << 20
+
+// recordReader yields records along with the line they started on. Errors
+// wrapped in rowError only affect that record and reading can continue.
+type recordReader interface {
+	Next() (line int, record Record, err error)
+}
+
+type rowError struct {
+	err error
+}
+
+func (e rowError) Error() string {
+	return e.err.Error()
+}
+
+func newRecordReader(r io.Reader, format Format) (recordReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+		return &jsonlReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, apperror.NewBadRequest("Failed to read CSV header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, apperror.NewBadRequest(fmt.Sprintf("Unknown CSV column %q", name))
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"username", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, apperror.NewBadRequest(fmt.Sprintf("CSV is missing the %q column", required))
+		}
+	}
+	reader.FieldsPerRecord = len(header)
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (int, Record, error) {
+	fields, err := c.reader.Read()
+	// Field positions are only known for records that were parsed
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && !errors.Is(err, csv.ErrFieldCount) {
+		return parseErr.StartLine, Record{}, rowError{parseErr.Err}
+	}
+	if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+		return 0, Record{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	if err != nil {
+		return line, Record{}, rowError{fmt.Errorf("expected %d fields, got %d", len(c.columns), len(fields))}
+	}
+
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	record := Record{
+		ID:           field("id"),
+		Username:     field("username"),
+		Email:        field("email"),
+		PasswordHash: field("password_hash"),
+	}
+	if value := field("email_verified"); value != "" {
+		record.EmailVerified, err = strconv.ParseBool(value)
+		if err != nil {
+			return line, record, rowError{fmt.Errorf("email_verified must be true or false")}
+		}
+	}
+	for name, dst := range map[string]**time.Time{"created_at": &record.CreatedAt, "updated_at": &record.UpdatedAt} {
+		value := field(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return line, record, rowError{fmt.Errorf("%s must be an RFC 3339 timestamp", name)}
+		}
+		*dst = &t
+	}
+
+	return line, record, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (j *jsonlReader) Next() (int, Record, error) {
+	for j.scanner.Scan() {
+		j.line++
+		text := bytes.TrimSpace(j.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var record Record
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&record); err != nil {
+			return j.line, record, rowError{fmt.Errorf("invalid JSON: %w", err)}
+		}
+		return j.line, record, nil
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return j.line + 1, Record{}, err
+	}
+	return j.line, Record{}, io.EOF
+}
+
+// recordWriter writes records in one of the supported formats. Flush must be
+// called once all records are written.
+type recordWriter interface {
+	Write(record Record) error
+	Flush() error
+}
+
+func newRecordWriter(w io.Writer, format Format) (recordWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer}, nil
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(record Record) error {
+	return c.writer.Write([]string{
+		record.ID,
+		record.Username,
+		record.Email,
+		record.PasswordHash,
+		strconv.FormatBool(record.EmailVerified),
+		formatTime(record.CreatedAt),
+		formatTime(record.UpdatedAt),
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (j *jsonlWriter) Write(record Record) error {
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.buffered.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
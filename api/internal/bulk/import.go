@@ -0,0 +1,235 @@
+package bulk
+
+import (
+	"auth/internal/emails"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+type RowError struct {
+	Line    int    `json:"line"`
+	ID      string `json:"id,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Message string `json:"message"`
+}
+
+type Report struct {
+	DryRun   bool       `json:"dry_run"`
+	Total    int        `json:"total"`
+	Imported int        `json:"imported"`
+	Failed   int        `json:"failed"`
+	Errors   []RowError `json:"errors"`
+}
+
+type Importer struct {
+	recognizesHash func(hash string) bool
+
+	userRepo             repositories.UserRepository
+	accountTombstoneRepo repositories.AccountTombstoneRepository
+}
+
+// NewImporter takes recognizesHash to reject password hashes no verifier
+// understands, since those users could never log in.
+func NewImporter(db *sql.DB, recognizesHash func(hash string) bool) *Importer {
+	return &Importer{
+		recognizesHash:       recognizesHash,
+		userRepo:             repositories.NewUserRepository(db),
+		accountTombstoneRepo: repositories.NewAccountTombstoneRepository(db),
+	}
+}
+
+// Import validates and inserts every record in r, collecting a report of the
+// rows that failed instead of stopping at the first one. With dryRun set every
+// check runs but nothing is written. The returned error is only set when the
+// input itself can't be read.
+func (i *Importer) Import(r io.Reader, format Format, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Errors: []RowError{}}
+
+	reader, err := newRecordReader(r, format)
+	if err != nil {
+		return report, err
+	}
+
+	// Rows must not conflict with each other any more than with existing users
+	seenIDs := make(map[ulid.ULID]int)
+	seenEmails := make(map[string]int)
+	seenUsernames := make(map[string]int)
+
+	for {
+		line, record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr rowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			report.fail(line, record, rowErr.Error())
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Total++
+
+		user, message := i.toModel(record)
+		if message != "" {
+			report.fail(line, record, message)
+			continue
+		}
+		id := ulid.ULID(user.ID)
+
+		if first, ok := seenIDs[id]; ok {
+			report.fail(line, record, fmt.Sprintf("Duplicate id, first used on line %d", first))
+			continue
+		}
+		if first, ok := seenEmails[user.Email]; ok {
+			report.fail(line, record, fmt.Sprintf("Duplicate email, first used on line %d", first))
+			continue
+		}
+		if first, ok := seenUsernames[user.Username]; ok {
+			report.fail(line, record, fmt.Sprintf("Duplicate username, first used on line %d", first))
+			continue
+		}
+		seenIDs[id] = line
+		seenEmails[user.Email] = line
+		seenUsernames[user.Username] = line
+
+		exists, err := i.userRepo.Exists(id)
+		if err != nil {
+			return report, err
+		}
+		if exists {
+			report.fail(line, record, "User with this id already exists")
+			continue
+		}
+
+		conflict, err := i.userRepo.WillConflict(user)
+		if err != nil {
+			return report, err
+		}
+		if conflict {
+			report.fail(line, record, "Username or email already in use")
+			continue
+		}
+
+		// The addresses and usernames of recently deleted accounts stay reserved
+		reserved, err := i.accountTombstoneRepo.Exists(repositories.AccountTombstoneEmail, user.Email)
+		if err != nil {
+			return report, err
+		}
+		if !reserved {
+			if reserved, err = i.accountTombstoneRepo.Exists(repositories.AccountTombstoneUsername, user.Username); err != nil {
+				return report, err
+			}
+		}
+		if reserved {
+			report.fail(line, record, "Username or email belonged to a recently deleted account")
+			continue
+		}
+
+		if !dryRun {
+			inserted, err := i.userRepo.Import(user)
+			if err != nil {
+				return report, err
+			}
+			if !inserted {
+				report.fail(line, record, "User was created concurrently")
+				continue
+			}
+		}
+		report.Imported++
+	}
+
+	return report, nil
+}
+
+func (r *Report) fail(line int, record Record, message string) {
+	r.Failed++
+	r.Errors = append(r.Errors, RowError{
+		Line:    line,
+		ID:      record.ID,
+		Email:   record.Email,
+		Message: message,
+	})
+}
+
+// toModel validates a record and converts it, returning a message describing
+// the first problem found.
+func (i *Importer) toModel(record Record) (model.Users, string) {
+	username := strings.TrimSpace(record.Username)
+	if username == "" {
+		return model.Users{}, "username is required"
+	}
+
+	email := strings.TrimSpace(record.Email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return model.Users{}, "email is not a valid address"
+	}
+
+	// Users without a password can still sign in after a password reset
+	if record.PasswordHash != "" && !i.recognizesHash(record.PasswordHash) {
+		return model.Users{}, "password_hash is not in a supported format"
+	}
+
+	now := time.Now()
+	createdAt := now
+	if record.CreatedAt != nil {
+		createdAt = *record.CreatedAt
+	}
+	updatedAt := createdAt
+	if record.UpdatedAt != nil {
+		updatedAt = *record.UpdatedAt
+	}
+	if createdAt.After(now) || updatedAt.Before(createdAt) {
+		return model.Users{}, "created_at must not be in the future or after updated_at"
+	}
+
+	id, err := parseID(record.ID, createdAt)
+	if err != nil {
+		return model.Users{}, "id must be a ULID or UUID"
+	}
+
+	return model.Users{
+		ID:            id.Bytes(),
+		Username:      username,
+		Email:         email,
+		PasswordHash:  record.PasswordHash,
+		EmailVerified: record.EmailVerified,
+		Active:        true,
+		Locale:        emails.DefaultLocale,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+	}, ""
+}
+
+// parseID accepts the user_ prefixed IDs this service exports, bare ULIDs and
+// UUIDs, which are the same size and are stored unchanged. Missing IDs are
+// generated from the creation time so they still sort by signup order.
+func parseID(id string, createdAt time.Time) (ulid.ULID, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ulid.MustNew(ulid.Timestamp(createdAt), ulid.DefaultEntropy()), nil
+	}
+
+	if parsed, err := ulid.Parse(strings.TrimPrefix(id, "user_")); err == nil {
+		return parsed, nil
+	}
+
+	decoded, err := hex.DecodeString(strings.ReplaceAll(id, "-", ""))
+	if err != nil || len(decoded) != len(ulid.ULID{}) {
+		return ulid.Zero, errors.New("invalid id")
+	}
+	return ulid.ULID(decoded), nil
+}
@@ -0,0 +1,248 @@
+package devmail
+
+import (
+	"auth/internal/apperror"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var hrefPattern = regexp.MustCompile(`href="([^"]+)"`)
+
+// linkKinds maps frontend paths that emails link to onto the names used by
+// the inbox and its API.
+var linkKinds = map[string]string{
+	"/verify-email":         "verify",
+	"/reset-password":       "reset",
+	"/unlock-account":       "unlock",
+	"/confirm-email-change": "email-change",
+	"/cancel-email-change":  "email-change-cancel",
+	"/cancel-deletion":      "deletion-cancel",
+	"/download-export":      "data-export",
+}
+
+type Link struct {
+	Kind  string `json:"kind"`
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+type Message struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      []string  `json:"to"`
+	Subject string    `json:"subject"`
+	Date    time.Time `json:"date"`
+	HTML    string    `json:"-"`
+	Links   []Link    `json:"links"`
+}
+
+// Inbox reads the .eml files written by the file email transport.
+type Inbox struct {
+	dir string
+}
+
+func NewInbox(dir string) *Inbox {
+	return &Inbox{dir: dir}
+}
+
+// List returns messages newest first, only those sent to address if it is
+// not empty.
+func (i *Inbox) List(address string) ([]Message, error) {
+	entries, err := os.ReadDir(i.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Message{}, nil
+		}
+		return nil, err
+	}
+
+	messages := []Message{}
+	// File names start with the time they were written
+	for _, entry := range slices.Backward(entries) {
+		id, ok := strings.CutSuffix(entry.Name(), ".eml")
+		if !ok || entry.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+
+		message, err := i.read(id)
+		if err != nil {
+			return nil, err
+		}
+		if address == "" || message.SentTo(address) {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (i *Inbox) Get(id string) (Message, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return Message{}, apperror.NewNotFound("Message not found")
+	}
+
+	message, err := i.read(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return Message{}, apperror.NewNotFound("Message not found")
+	}
+	return message, err
+}
+
+// Clear deletes every captured message.
+func (i *Inbox) Clear() error {
+	entries, err := os.ReadDir(i.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".eml") {
+			if err := os.Remove(filepath.Join(i.dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m Message) SentTo(address string) bool {
+	for _, to := range m.To {
+		if strings.EqualFold(to, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Link returns the first link of the given kind, or any kind if empty.
+func (m Message) Link(kind string) (Link, bool) {
+	for _, link := range m.Links {
+		if kind == "" || link.Kind == kind {
+			return link, true
+		}
+	}
+	return Link{}, false
+}
+
+func (i *Inbox) read(id string) (Message, error) {
+	file, err := os.Open(filepath.Join(i.dir, id+".eml"))
+	if err != nil {
+		return Message{}, err
+	}
+	defer file.Close()
+
+	parsed, err := mail.ReadMessage(file)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to parse %s: %w", id, err)
+	}
+
+	message := Message{ID: id}
+	decoder := new(mime.WordDecoder)
+	if subject, err := decoder.DecodeHeader(parsed.Header.Get("Subject")); err == nil {
+		message.Subject = subject
+	}
+	if from, err := parsed.Header.AddressList("From"); err == nil && len(from) > 0 {
+		message.From = from[0].Address
+	}
+	if to, err := parsed.Header.AddressList("To"); err == nil {
+		for _, address := range to {
+			message.To = append(message.To, address.Address)
+		}
+	}
+	if date, err := parsed.Header.Date(); err == nil {
+		message.Date = date
+	}
+
+	message.HTML, err = htmlBody(parsed.Header.Get("Content-Type"), parsed.Header.Get("Content-Transfer-Encoding"), parsed.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to read %s: %w", id, err)
+	}
+	message.Links = findLinks(message.HTML)
+
+	return message, nil
+}
+
+// htmlBody returns the HTML part of a body, looking inside multipart bodies.
+func htmlBody(contentType string, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+
+			found, err := htmlBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil || found != "" {
+				return found, err
+			}
+		}
+	}
+
+	if mediaType != "text/html" {
+		return "", nil
+	}
+
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	decoded, err := io.ReadAll(body)
+	return string(decoded), err
+}
+
+func findLinks(body string) []Link {
+	links := []Link{}
+	for _, match := range hrefPattern.FindAllStringSubmatch(body, -1) {
+		href := html.UnescapeString(match[1])
+		parsed, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+
+		kind := linkKind(parsed.Path)
+		if kind == "" || slices.ContainsFunc(links, func(link Link) bool { return link.URL == href }) {
+			continue
+		}
+		links = append(links, Link{Kind: kind, URL: href, Token: parsed.Query().Get("token")})
+	}
+	return links
+}
+
+// linkKind matches on the end of the path, since the frontend may be served
+// under a prefix.
+func linkKind(path string) string {
+	for suffix, kind := range linkKinds {
+		if strings.HasSuffix(path, suffix) {
+			return kind
+		}
+	}
+	return ""
+}
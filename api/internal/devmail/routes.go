@@ -0,0 +1,164 @@
+package devmail
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/httputil"
+	_ "embed"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MountPath is where the inbox is served. Its pages link to each other by
+// absolute path.
+const MountPath = "/_dev/mail"
+
+//go:embed templates/inbox.html
+var inboxTemplate string
+
+//go:embed templates/message.html
+var messageTemplate string
+
+var (
+	inboxPage   = template.Must(template.New("inbox").Parse(inboxTemplate))
+	messagePage = template.Must(template.New("message").Parse(messageTemplate))
+)
+
+// LatestResponse is the newest message for an address along with the token
+// from its link, for end-to-end tests to poll.
+type LatestResponse struct {
+	Message
+	Token string `json:"token"`
+}
+
+// Router serves the inbox pages along with a JSON API under /api. It has no
+// authentication and exposes every captured token, so it must only be
+// mounted when explicitly enabled for development.
+func Router(inbox *Inbox) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		messages, err := inbox.List(r.URL.Query().Get("to"))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		renderPage(w, inboxPage, map[string]any{
+			"Base":     MountPath,
+			"Dir":      inbox.dir,
+			"Messages": messages,
+		})
+	})
+
+	r.Post("/clear", func(w http.ResponseWriter, r *http.Request) {
+		if err := inbox.Clear(); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		http.Redirect(w, r, MountPath, http.StatusSeeOther)
+	})
+
+	r.Get("/{messageID}", func(w http.ResponseWriter, r *http.Request) {
+		message, err := inbox.Get(chi.URLParam(r, "messageID"))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		renderPage(w, messagePage, map[string]any{
+			"Base":    MountPath,
+			"Message": message,
+		})
+	})
+
+	r.Get("/{messageID}/html", func(w http.ResponseWriter, r *http.Request) {
+		message, err := inbox.Get(chi.URLParam(r, "messageID"))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		// Email HTML is shown as is, so keep its scripts and forms inert
+		w.Header().Set("Content-Security-Policy", "sandbox allow-popups allow-popups-to-escape-sandbox")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(message.HTML))
+	})
+
+	r.Get("/api/messages", func(w http.ResponseWriter, r *http.Request) {
+		messages, err := inbox.List(r.URL.Query().Get("to"))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, messages)
+	})
+
+	// The newest message to an address with a link of the given kind, so a
+	// test can poll for the token it is waiting on
+	r.Get("/api/messages/latest", func(w http.ResponseWriter, r *http.Request) {
+		to := r.URL.Query().Get("to")
+		if to == "" {
+			httputil.HandleError(w, apperror.NewBadRequest("to is required"))
+			return
+		}
+		kind := r.URL.Query().Get("kind")
+
+		messages, err := inbox.List(to)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		for _, message := range messages {
+			if link, ok := message.Link(kind); ok || kind == "" {
+				httputil.JSONResponse(w, http.StatusOK, LatestResponse{Message: message, Token: link.Token})
+				return
+			}
+		}
+
+		httputil.HandleError(w, apperror.NewNotFound("No matching message"))
+	})
+
+	r.Get("/api/messages/{messageID}", func(w http.ResponseWriter, r *http.Request) {
+		message, err := inbox.Get(chi.URLParam(r, "messageID"))
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		httputil.JSONResponse(w, http.StatusOK, message)
+	})
+
+	r.Delete("/api/messages", func(w http.ResponseWriter, r *http.Request) {
+		if err := inbox.Clear(); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return r
+}
+
+func renderPage(w http.ResponseWriter, page *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		log.Printf("[ERROR] Failed to render dev mail page: %v", err)
+	}
+}
+
+// handleError passes API errors through and logs anything else, such as a
+// malformed file, as an internal error.
+func handleError(w http.ResponseWriter, err error) {
+	if _, ok := err.(apperror.HTTPError); !ok {
+		log.Printf("[ERROR] Dev mail inbox: %v", err)
+		err = apperror.NewInternalServerError("Failed to read captured mail")
+	}
+	httputil.HandleError(w, err)
+}
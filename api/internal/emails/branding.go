@@ -0,0 +1,40 @@
+package emails
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Branding is what sets one deployment's emails apart from another's. Its
+// fields are available to every template and catalog string.
+type Branding struct {
+	ServiceName  string
+	SupportEmail string
+	// Optional, shown above the heading
+	LogoURL string
+	// Button background and link colour, as #rgb or #rrggbb
+	PrimaryColor string
+	// Button text colour, as #rgb or #rrggbb
+	PrimaryTextColor string
+	// Optional postal address shown in the footer, which some jurisdictions
+	// require in commercial email
+	FooterAddress string
+}
+
+func (b Branding) validate() error {
+	if b.LogoURL != "" {
+		logoURL, err := url.Parse(b.LogoURL)
+		if err != nil || (logoURL.Scheme != "https" && logoURL.Scheme != "http") || logoURL.Host == "" {
+			return fmt.Errorf("logo URL %q must be an absolute http or https URL", b.LogoURL)
+		}
+	}
+	for name, color := range map[string]string{"primary": b.PrimaryColor, "primary text": b.PrimaryTextColor} {
+		if !hexColorPattern.MatchString(color) {
+			return fmt.Errorf("%s color %q must be a hex color such as #1a2b3c", name, color)
+		}
+	}
+	return nil
+}
@@ -1,112 +1,453 @@
 package emails
 
 import (
-	_ "embed"
-	"html/template"
-	"log"
-	"strings"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/outbox"
+	"auth/internal/repositories"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
 
-	"github.com/resend/resend-go/v3"
+	"github.com/oklog/ulid/v2"
 )
 
-//go:embed templates/forgot-password.html
-var forgotPasswordTemplate string
+// OutboxKind identifies queued emails in the outbox.
+const OutboxKind = "email"
 
-//go:embed templates/verify-email.html
-var verifyEmailTemplate string
+const (
+	TemplateForgotPassword = "forgot-password"
+	TemplateVerifyEmail    = "verify-email"
+	TemplateAccountLocked  = "account-locked"
 
-type EmailService struct {
-	client       *resend.Client
-	from         string
-	frontendURL  string
-	serviceName  string
-	supportEmail string
+	TemplateConfirmEmailChange   = "confirm-email-change"
+	TemplateEmailChangeRequested = "email-change-requested"
+
+	TemplatePasswordChanged = "password-changed"
+	TemplateAccountExists   = "account-exists"
+
+	TemplateAccountDeletionScheduled = "account-deletion-scheduled"
+
+	TemplateDataExportReady = "data-export-ready"
+)
+
+// Message is an email waiting in the outbox. It holds what the template needs
+// rather than the rendered email, so template fixes apply to queued mail.
+type Message struct {
+	// The user the email is sent to or about, mail queued before this was
+	// recorded has none
+	UserID      ulid.ULID `json:"user_id,omitzero"`
+	Template    string    `json:"template"`
+	To          string    `json:"to"`
+	Username    string    `json:"username"`
+	Token       string    `json:"token"`
+	LockedUntil time.Time `json:"locked_until,omitzero"`
+	NewEmail    string    `json:"new_email,omitempty"`
+	DeleteAfter time.Time `json:"delete_after,omitzero"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+	// The recipient's locale, mail queued before locales existed has none
+	Locale string `json:"locale,omitempty"`
 }
 
-func NewEmailService(resendAPIKey string, from string, frontendURL string, serviceName string, supportEmail string) (*EmailService, error) {
-	return &EmailService{
-		client:       resend.NewClient(resendAPIKey),
-		from:         from,
-		frontendURL:  frontendURL,
-		serviceName:  serviceName,
-		supportEmail: supportEmail,
-	}, nil
+type EmailService struct {
+	db          *sql.DB
+	sender      EmailSender
+	templates   *Templates
+	locales     *Locales
+	outbox      *outbox.Outbox
+	from        string
+	frontendURL string
+	branding    Branding
+
+	emailMessageRepo     repositories.EmailMessageRepository
+	emailSuppressionRepo repositories.EmailSuppressionRepository
 }
 
-func (s *EmailService) SendEmail(to []string, html string, subject string) {
-	params := &resend.SendEmailRequest{
-		From:    s.from,
-		To:      to,
-		Html:    html,
-		Subject: subject,
+// NewEmailService renders every template in every locale before returning,
+// so a broken template or catalog is reported at startup rather than when
+// the first affected email is sent.
+func NewEmailService(db *sql.DB, sender EmailSender, templates *Templates, locales *Locales, from string, frontendURL string, branding Branding, outbox *outbox.Outbox) (*EmailService, error) {
+	if err := branding.validate(); err != nil {
+		return nil, err
 	}
 
-	_, err := s.client.Emails.Send(params)
-	if err != nil {
-		log.Printf("[ERROR] Issue sending email %+v\n", params)
-		return
+	s := &EmailService{
+		db:          db,
+		sender:      sender,
+		templates:   templates,
+		locales:     locales,
+		outbox:      outbox,
+		from:        from,
+		frontendURL: frontendURL,
+		branding:    branding,
+
+		emailMessageRepo:     repositories.NewEmailMessageRepository(db),
+		emailSuppressionRepo: repositories.NewEmailSuppressionRepository(db),
 	}
-	// TODO log email sending, then add a webhook for email status reporting
+
+	for _, name := range templateNames {
+		for _, locale := range locales.List() {
+			_, _, _, err := s.render(Message{
+				Template:    name,
+				To:          "user@example.com",
+				Username:    "user",
+				Token:       "token",
+				LockedUntil: time.Now(),
+				DeleteAfter: time.Now(),
+				ExpiresAt:   time.Now(),
+				Locale:      locale,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s email in locale %s: %w", name, locale, err)
+			}
+		}
+	}
+
+	return s, nil
 }
 
-func (s *EmailService) SendForgotPasswordEmail(to string, username string, resetToken string) {
-	resetURL := s.frontendURL + "/reset-password?token=" + resetToken
+// MatchLocale picks the supported locale best suited to an Accept-Language
+// header.
+func (s *EmailService) MatchLocale(acceptLanguage string) string {
+	return s.locales.Match(acceptLanguage)
+}
 
-	tmpl, err := template.New("forgot-password").Parse(forgotPasswordTemplate)
-	if err != nil {
-		log.Printf("[ERROR] Failed to parse forgot password template: %v", err)
-		return
-	}
+// SupportedLocale returns the canonical form of locale if emails can be sent
+// in it.
+func (s *EmailService) SupportedLocale(locale string) (string, bool) {
+	return s.locales.Supported(locale)
+}
 
-	type forgotPasswordData struct {
-		Username    string
-		ResetLink   string
-		AuthURL     string
-		ServiceName string
-	}
+// SendForgotPasswordEmail queues the email through db, which should be the
+// transaction that created the reset token.
+func (s *EmailService) SendForgotPasswordEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, resetToken string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplateForgotPassword,
+		To:       to,
+		Username: username,
+		Token:    resetToken,
+		Locale:   locale,
+	})
+}
 
-	data := forgotPasswordData{
+// SendVerifyEmail queues the email through db, which should be the
+// transaction that created the verification token.
+func (s *EmailService) SendVerifyEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, verifyToken string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplateVerifyEmail,
+		To:       to,
+		Username: username,
+		Token:    verifyToken,
+		Locale:   locale,
+	})
+}
+
+// SendAccountLockedEmail queues the email through db, which should be the
+// transaction that locked the account.
+func (s *EmailService) SendAccountLockedEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, unlockToken string, lockedUntil time.Time) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:      userID,
+		Template:    TemplateAccountLocked,
+		To:          to,
 		Username:    username,
-		ResetLink:   resetURL,
-		AuthURL:     s.frontendURL,
-		ServiceName: s.serviceName,
+		Token:       unlockToken,
+		LockedUntil: lockedUntil,
+		Locale:      locale,
+	})
+}
+
+// SendConfirmEmailChangeEmail queues the email to the new address through
+// db, which should be the transaction that created the change request.
+func (s *EmailService) SendConfirmEmailChangeEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, confirmToken string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplateConfirmEmailChange,
+		To:       to,
+		Username: username,
+		Token:    confirmToken,
+		Locale:   locale,
+	})
+}
+
+// SendEmailChangeRequestedEmail queues the notice to the current address
+// through db, which should be the transaction that created the change
+// request.
+func (s *EmailService) SendEmailChangeRequestedEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, newEmail string, cancelToken string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplateEmailChangeRequested,
+		To:       to,
+		Username: username,
+		Token:    cancelToken,
+		NewEmail: newEmail,
+		Locale:   locale,
+	})
+}
+
+// SendPasswordChangedEmail queues the email through db, which should be the
+// transaction that changed the password.
+func (s *EmailService) SendPasswordChangedEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplatePasswordChanged,
+		To:       to,
+		Username: username,
+		Locale:   locale,
+	})
+}
+
+// SendAccountExistsEmail queues the notice sent to the owner of an address
+// someone tried to register with again.
+func (s *EmailService) SendAccountExistsEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:   userID,
+		Template: TemplateAccountExists,
+		To:       to,
+		Username: username,
+		Locale:   locale,
+	})
+}
+
+// SendAccountDeletionScheduledEmail queues the email through db, which
+// should be the transaction that scheduled the deletion.
+func (s *EmailService) SendAccountDeletionScheduledEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, cancelToken string, deleteAfter time.Time) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:      userID,
+		Template:    TemplateAccountDeletionScheduled,
+		To:          to,
+		Username:    username,
+		Token:       cancelToken,
+		DeleteAfter: deleteAfter,
+		Locale:      locale,
+	})
+}
+
+// SendDataExportReadyEmail queues the email through db, which should be the
+// transaction that stored the export.
+func (s *EmailService) SendDataExportReadyEmail(db repositories.DB, userID ulid.ULID, to string, username string, locale string, downloadToken string, expiresAt time.Time) error {
+	return s.outbox.Enqueue(db, OutboxKind, Message{
+		UserID:    userID,
+		Template:  TemplateDataExportReady,
+		To:        to,
+		Username:  username,
+		Token:     downloadToken,
+		ExpiresAt: expiresAt,
+		Locale:    locale,
+	})
+}
+
+// Deliver renders and sends a queued email, recording the send so that the
+// provider's status events can be matched to it. Addresses known to be
+// undeliverable are skipped. It is the outbox handler for OutboxKind.
+func (s *EmailService) Deliver(messageID ulid.ULID, payload []byte) error {
+	var message Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("failed to decode email: %w", err)
 	}
 
-	var htmlBuilder strings.Builder
-	if err := tmpl.Execute(&htmlBuilder, data); err != nil {
-		log.Printf("[ERROR] Failed to execute forgot password template: %v", err)
-		return
+	record := model.EmailMessages{
+		ID:        messageID.Bytes(),
+		Template:  message.Template,
+		Recipient: message.To,
+		Status:    repositories.EmailMessageSent,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if message.UserID != (ulid.ULID{}) {
+		userID := message.UserID.Bytes()
+		record.UserID = &userID
 	}
 
-	s.SendEmail([]string{to}, htmlBuilder.String(), "Reset your password - "+s.serviceName)
-}
+	undeliverable, err := s.emailSuppressionRepo.Exists(message.To)
+	if err != nil {
+		return err
+	}
+	if undeliverable {
+		record.Status = repositories.EmailMessageSuppressed
+		return s.emailMessageRepo.Create(record)
+	}
 
-func (s *EmailService) SendVerifyEmail(to string, username string, verifyToken string) {
-	verifyURL := s.frontendURL + "/verify-email?token=" + verifyToken
+	subject, html, text, err := s.render(message)
+	if err != nil {
+		return err
+	}
 
-	tmpl, err := template.New("verify-email").Parse(verifyEmailTemplate)
+	providerMessageID, err := s.sender.Send(Email{
+		ID:      "outbox-" + messageID.String(),
+		From:    s.from,
+		To:      []string{message.To},
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
 	if err != nil {
-		log.Printf("[ERROR] Failed to parse verify email template: %v", err)
-		return
+		return err
 	}
 
-	type verifyEmailData struct {
-		Username     string
-		VerifyLink   string
-		SupportEmail string
+	if providerMessageID != "" {
+		record.ProviderMessageID = &providerMessageID
 	}
+	// The email is already out and returning an error would send it again, so
+	// a failure to record it is only logged by the repository
+	s.emailMessageRepo.Create(record)
+	return nil
+}
 
-	data := verifyEmailData{
-		Username:     username,
-		VerifyLink:   verifyURL,
-		SupportEmail: s.supportEmail,
+// IsUndeliverable reports whether mail to address has hard bounced, in which
+// case no more is sent to it until the user changes their address.
+func (s *EmailService) IsUndeliverable(address string) (bool, error) {
+	return s.emailSuppressionRepo.Exists(address)
+}
+
+func (s *EmailService) render(message Message) (subject string, html string, text string, err error) {
+	tmpl, ok := s.templates.templates[message.Template]
+	if !ok {
+		return "", "", "", fmt.Errorf("unknown email template %q", message.Template)
 	}
 
-	var htmlBuilder strings.Builder
-	if err := tmpl.Execute(&htmlBuilder, data); err != nil {
-		log.Printf("[ERROR] Failed to execute verify email template: %v", err)
-		return
+	locale := message.Locale
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	data := s.templateData(message)
+	subject, err = s.locales.translate(locale, message.Template+".subject", data)
+	if err != nil {
+		return "", "", "", err
 	}
+	html, text, err = tmpl.render(s.locales, locale, data)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return subject, html, text, nil
+}
 
-	s.SendEmail([]string{to}, htmlBuilder.String(), "Verify your email - "+s.serviceName)
+type forgotPasswordData struct {
+	Branding
+	Username  string
+	ResetLink string
+	AuthURL   string
+}
+
+type verifyEmailData struct {
+	Branding
+	Username   string
+	VerifyLink string
+}
+
+type accountLockedData struct {
+	Branding
+	Username    string
+	UnlockLink  string
+	LockedUntil string
+}
+
+type confirmEmailChangeData struct {
+	Branding
+	Username    string
+	ConfirmLink string
+}
+
+type emailChangeRequestedData struct {
+	Branding
+	Username   string
+	NewEmail   string
+	CancelLink string
+}
+
+type passwordChangedData struct {
+	Branding
+	Username  string
+	ResetLink string
+}
+
+type accountExistsData struct {
+	Branding
+	Username  string
+	ResetLink string
+}
+
+type accountDeletionScheduledData struct {
+	Branding
+	Username    string
+	CancelLink  string
+	DeleteAfter string
+}
+
+type dataExportReadyData struct {
+	Branding
+	Username     string
+	DownloadLink string
+	ExpiresAt    string
+}
+
+// templateData returns the data shared by a message's subject and its HTML
+// and text templates.
+func (s *EmailService) templateData(message Message) any {
+	switch message.Template {
+	case TemplateForgotPassword:
+		return forgotPasswordData{
+			Branding:  s.branding,
+			Username:  message.Username,
+			ResetLink: s.frontendURL + "/reset-password?token=" + message.Token,
+			AuthURL:   s.frontendURL,
+		}
+	case TemplateVerifyEmail:
+		return verifyEmailData{
+			Branding:   s.branding,
+			Username:   message.Username,
+			VerifyLink: s.frontendURL + "/verify-email?token=" + message.Token,
+		}
+	case TemplateAccountLocked:
+		return accountLockedData{
+			Branding:   s.branding,
+			Username:   message.Username,
+			UnlockLink: s.frontendURL + "/unlock-account?token=" + message.Token,
+			// Month names would need translating, this format reads the same everywhere
+			LockedUntil: message.LockedUntil.UTC().Format("2006-01-02 15:04 MST"),
+		}
+	case TemplateConfirmEmailChange:
+		return confirmEmailChangeData{
+			Branding:    s.branding,
+			Username:    message.Username,
+			ConfirmLink: s.frontendURL + "/confirm-email-change?token=" + message.Token,
+		}
+	case TemplateEmailChangeRequested:
+		return emailChangeRequestedData{
+			Branding:   s.branding,
+			Username:   message.Username,
+			NewEmail:   message.NewEmail,
+			CancelLink: s.frontendURL + "/cancel-email-change?token=" + message.Token,
+		}
+	case TemplateAccountDeletionScheduled:
+		return accountDeletionScheduledData{
+			Branding:    s.branding,
+			Username:    message.Username,
+			CancelLink:  s.frontendURL + "/cancel-deletion?token=" + message.Token,
+			DeleteAfter: message.DeleteAfter.UTC().Format("2006-01-02 15:04 MST"),
+		}
+	case TemplateDataExportReady:
+		return dataExportReadyData{
+			Branding:     s.branding,
+			Username:     message.Username,
+			DownloadLink: s.frontendURL + "/download-export?token=" + message.Token,
+			ExpiresAt:    message.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"),
+		}
+	case TemplateAccountExists:
+		return accountExistsData{
+			Branding:  s.branding,
+			Username:  message.Username,
+			ResetLink: s.frontendURL + "/forgot-password",
+		}
+	case TemplatePasswordChanged:
+		return passwordChangedData{
+			Branding:  s.branding,
+			Username:  message.Username,
+			ResetLink: s.frontendURL + "/forgot-password",
+		}
+	}
+	return nil
 }
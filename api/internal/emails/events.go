@@ -0,0 +1,94 @@
+package emails
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// ResendEvent is a status update Resend sends to its webhook for a message
+// it was given.
+type ResendEvent struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		EmailID string   `json:"email_id"`
+		To      []string `json:"to"`
+		Bounce  *struct {
+			Type    string `json:"type"`
+			SubType string `json:"subType"`
+			Message string `json:"message"`
+		} `json:"bounce,omitempty"`
+	} `json:"data"`
+}
+
+// A message's status only moves forward through these, since events can
+// arrive out of order and a bounce or complaint outranks an open
+var statusesBefore = map[string][]string{
+	repositories.EmailMessageDelivered:  {repositories.EmailMessageSent},
+	repositories.EmailMessageOpened:     {repositories.EmailMessageSent, repositories.EmailMessageDelivered},
+	repositories.EmailMessageBounced:    {repositories.EmailMessageSent, repositories.EmailMessageDelivered, repositories.EmailMessageOpened},
+	repositories.EmailMessageComplained: {repositories.EmailMessageSent, repositories.EmailMessageDelivered, repositories.EmailMessageOpened},
+}
+
+// RecordResendEvent updates the status of the message an event is about.
+// A hard bounce also marks the recipient undeliverable. Events of other
+// types, and repeats of an event already recorded, are ignored.
+func (s *EmailService) RecordResendEvent(event ResendEvent) error {
+	var status string
+	var detail *string
+	hardBounce := false
+	switch event.Type {
+	case "email.delivered":
+		status = repositories.EmailMessageDelivered
+	case "email.opened":
+		status = repositories.EmailMessageOpened
+	case "email.complained":
+		status = repositories.EmailMessageComplained
+	case "email.bounced":
+		status = repositories.EmailMessageBounced
+		// Resend only reports bounces the receiving server gave up on, but
+		// passes through the type when it knows it
+		hardBounce = event.Data.Bounce == nil || strings.EqualFold(event.Data.Bounce.Type, "Permanent")
+		if event.Data.Bounce != nil && event.Data.Bounce.Message != "" {
+			detail = &event.Data.Bounce.Message
+		}
+	default:
+		return nil
+	}
+
+	return repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		emailMessageRepo := repositories.NewEmailMessageRepository(tx)
+		message, err := emailMessageRepo.AdvanceStatus(event.Data.EmailID, status, statusesBefore[status], detail)
+		if err != nil {
+			return err
+		}
+
+		if !hardBounce {
+			return nil
+		}
+
+		// Messages sent before sends were recorded are only known by the
+		// recipients in the event
+		recipients := event.Data.To
+		if message != nil {
+			recipients = []string{message.Recipient}
+		}
+
+		emailSuppressionRepo := repositories.NewEmailSuppressionRepository(tx)
+		for _, recipient := range recipients {
+			err := emailSuppressionRepo.Create(model.EmailSuppressions{
+				Address:   recipient,
+				Reason:    repositories.EmailSuppressionHardBounce,
+				Detail:    detail,
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
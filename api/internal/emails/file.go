@@ -0,0 +1,79 @@
+package emails
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSender saves each email as an .eml file in a directory instead of
+// sending it, for local development and tests.
+type FileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create email directory: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send names files by time so they list in the order they were sent. They
+// are written under a temporary name and renamed, so anything watching the
+// directory never sees a partial file.
+func (s *FileSender) Send(email Email) (string, error) {
+	var message bytes.Buffer
+	if err := email.WriteMIME(&message); err != nil {
+		return "", err
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + sanitizeFilename(email.ID) + ".eml"
+	path := filepath.Join(s.dir, name)
+	temporary := filepath.Join(s.dir, "."+name+".tmp")
+
+	if err := os.WriteFile(temporary, message.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		os.Remove(temporary)
+		return "", fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return "", nil
+}
+
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// LogSender writes each email to the log instead of sending it, showing the
+// plain text body where there is one since it is the readable one.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(email Email) (string, error) {
+	body := email.Text
+	if body == "" {
+		var message bytes.Buffer
+		if err := email.WriteMIME(&message); err != nil {
+			return "", err
+		}
+		body = message.String()
+	}
+
+	log.Printf("Email to %s: %s\n%s", strings.Join(email.To, ", "), email.Subject, body)
+	return "", nil
+}
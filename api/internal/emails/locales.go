@@ -0,0 +1,225 @@
+package emails
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used for users without a preference and for strings a
+// locale's catalog does not translate.
+const DefaultLocale = "en"
+
+//go:embed locales
+var localeFiles embed.FS
+
+// catalog holds a locale's translated strings. Each string is a text template
+// executed with the email's data, so it can refer to fields such as
+// {{.ServiceName}}.
+type catalog map[string]*texttemplate.Template
+
+// Locales are the message catalogs emails are translated with, one per
+// locale. Catalogs are JSON objects named <locale>.json mapping keys to
+// strings.
+type Locales struct {
+	catalogs map[string]catalog
+	// Canonical tags by their lower case form, since tags are case insensitive
+	tags map[string]string
+}
+
+// LoadLocales loads the embedded catalogs followed by any in dir, which may
+// be empty. A catalog in dir for an embedded locale replaces only the strings
+// it defines.
+func LoadLocales(dir string) (*Locales, error) {
+	locales := &Locales{catalogs: map[string]catalog{}, tags: map[string]string{}}
+
+	embedded, err := fs.Sub(localeFiles, "locales")
+	if err != nil {
+		return nil, err
+	}
+	if err := locales.load(embedded); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := locales.load(os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := locales.catalogs[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("missing catalog for default locale %q", DefaultLocale)
+	}
+
+	return locales, nil
+}
+
+func (l *Locales) load(fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tag := strings.TrimSuffix(name, ".json")
+		if !validLocaleTag(tag) {
+			return fmt.Errorf("invalid locale catalog name %s", name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to parse locale catalog %s: %w", name, err)
+		}
+
+		if canonical, ok := l.tags[strings.ToLower(tag)]; ok {
+			tag = canonical
+		} else {
+			l.tags[strings.ToLower(tag)] = tag
+			l.catalogs[tag] = catalog{}
+		}
+
+		for key, message := range messages {
+			tmpl, err := texttemplate.New(key).Option("missingkey=error").Parse(message)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q in locale catalog %s: %w", key, name, err)
+			}
+			l.catalogs[tag][key] = tmpl
+		}
+	}
+
+	return nil
+}
+
+// validLocaleTag reports whether tag looks like a BCP 47 language tag such
+// as en or pt-BR.
+func validLocaleTag(tag string) bool {
+	subtags := strings.Split(tag, "-")
+	if len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return false
+	}
+	for _, subtag := range subtags {
+		if subtag == "" || len(subtag) > 8 {
+			return false
+		}
+		for _, c := range subtag {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// List returns the tags of every loaded locale in sorted order.
+func (l *Locales) List() []string {
+	list := make([]string, 0, len(l.catalogs))
+	for tag := range l.catalogs {
+		list = append(list, tag)
+	}
+	slices.Sort(list)
+	return list
+}
+
+// Supported returns the canonical form of locale if it has a catalog.
+func (l *Locales) Supported(locale string) (string, bool) {
+	tag, ok := l.tags[strings.ToLower(locale)]
+	return tag, ok
+}
+
+// Match picks the locale best suited to an Accept-Language header, trying each
+// language range in order of preference and then its base language. It falls
+// back to DefaultLocale when nothing matches.
+func (l *Locales) Match(acceptLanguage string) string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []languageRange
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, languageRange{tag: tag, quality: quality})
+	}
+	slices.SortStableFunc(ranges, func(a, b languageRange) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		}
+		return 0
+	})
+
+	for _, r := range ranges {
+		if tag, ok := l.Supported(r.tag); ok {
+			return tag
+		}
+		if base, _, found := strings.Cut(r.tag, "-"); found {
+			if tag, ok := l.Supported(base); ok {
+				return tag
+			}
+		}
+	}
+
+	return DefaultLocale
+}
+
+// fallbacks returns the locales to look a string up in, from most to least
+// specific: pt-BR falls back to pt and then to DefaultLocale.
+func (l *Locales) fallbacks(locale string) []string {
+	var chain []string
+	for locale != "" {
+		if tag, ok := l.Supported(locale); ok && !slices.Contains(chain, tag) {
+			chain = append(chain, tag)
+		}
+		index := strings.LastIndex(locale, "-")
+		if index < 0 {
+			break
+		}
+		locale = locale[:index]
+	}
+	if !slices.Contains(chain, DefaultLocale) {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+// translate renders the string for key in locale, falling back to less
+// specific locales when it is not translated.
+func (l *Locales) translate(locale string, key string, data any) (string, error) {
+	for _, tag := range l.fallbacks(locale) {
+		tmpl, ok := l.catalogs[tag][key]
+		if !ok {
+			continue
+		}
+
+		var builder strings.Builder
+		if err := tmpl.Execute(&builder, data); err != nil {
+			return "", fmt.Errorf("failed to execute %q in locale %s: %w", key, tag, err)
+		}
+		return builder.String(), nil
+	}
+
+	return "", fmt.Errorf("missing translation for %q", key)
+}
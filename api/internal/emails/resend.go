@@ -0,0 +1,36 @@
+package emails
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/resend/resend-go/v3"
+)
+
+type ResendSender struct {
+	client *resend.Client
+}
+
+func NewResendSender(apiKey string) *ResendSender {
+	return &ResendSender{client: resend.NewClient(apiKey)}
+}
+
+// Send uses the email ID as the idempotency key, so Resend drops a retry of
+// a send that already went through. It returns the ID Resend assigned, which
+// its webhook events refer to.
+func (s *ResendSender) Send(email Email) (string, error) {
+	params := &resend.SendEmailRequest{
+		From:    email.From,
+		To:      email.To,
+		Html:    email.HTML,
+		Text:    email.Text,
+		Subject: email.Subject,
+	}
+
+	options := &resend.SendEmailOptions{IdempotencyKey: email.ID}
+	sent, err := s.client.Emails.SendWithOptions(context.Background(), params, options)
+	if err != nil {
+		return "", fmt.Errorf("failed to send %q: %w", email.Subject, err)
+	}
+	return sent.Id, nil
+}
@@ -0,0 +1,51 @@
+package emails
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/httputil"
+	"auth/internal/webhooks"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookTolerance is how far a signed event's timestamp may be from now.
+const webhookTolerance = 5 * time.Minute
+
+// WebhookRouter receives delivery status events from the email provider.
+// Resend signs them with the Standard Webhooks scheme under svix- prefixed
+// headers, using secret.
+func WebhookRouter(s *EmailService, secret string) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/resend", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = webhooks.Verify(secret, r.Header.Get("svix-id"), r.Header.Get("svix-timestamp"), body, r.Header.Get("svix-signature"), webhookTolerance)
+		if err != nil {
+			httputil.HandleError(w, apperror.NewUnauthorized("Invalid signature"))
+			return
+		}
+
+		var event ResendEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.RecordResendEvent(event); err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return r
+}
@@ -0,0 +1,115 @@
+package emails
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Email is a rendered message ready to be handed to a transport. ID stays
+// the same when a send is retried, so transports that support it can use it
+// to drop duplicates. Text is the plain text alternative to HTML.
+type Email struct {
+	ID      string
+	From    string
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// EmailSender is a transport for rendered emails. Send returns the ID the
+// provider assigned to the message, or an empty string for transports
+// without one.
+type EmailSender interface {
+	Send(email Email) (string, error)
+}
+
+// WriteMIME writes the email as an RFC 5322 message, as sent over SMTP or
+// saved to an .eml file. With both bodies set it is multipart/alternative
+// with the text part first, so clients pick the HTML when they can show it.
+func (e Email) WriteMIME(w io.Writer) error {
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", e.From, err)
+	}
+	to := make([]string, len(e.To))
+	for i, address := range e.To {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid to address %q: %w", address, err)
+		}
+		to[i] = parsed.String()
+	}
+
+	_, domain, _ := strings.Cut(from.Address, "@")
+
+	var message bytes.Buffer
+	header := func(name string, value string) {
+		message.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+e.ID+"@"+domain+">")
+	header("MIME-Version", "1.0")
+
+	parts := multipart.NewWriter(&message)
+	switch {
+	case e.Text == "":
+		header("Content-Type", "text/html; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		message.WriteString("\r\n")
+		err = writeQuotedPrintable(&message, e.HTML)
+	case e.HTML == "":
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		message.WriteString("\r\n")
+		err = writeQuotedPrintable(&message, e.Text)
+	default:
+		header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+		message.WriteString("\r\n")
+		err = writeAlternatives(parts, e.Text, e.HTML)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(message.Bytes())
+	return err
+}
+
+func writeAlternatives(parts *multipart.Writer, text string, html string) error {
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		partWriter, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeQuotedPrintable(partWriter, part.body); err != nil {
+			return err
+		}
+	}
+	return parts.Close()
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,138 @@
+package emails
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "implicit"
+	SMTPTLSNone     = "none"
+
+	smtpTimeout = 30 * time.Second
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLS is starttls to upgrade a plain connection, implicit to connect
+	// over TLS from the start, usually on port 465, or none
+	TLS string
+}
+
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	switch config.TLS {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q, expected starttls, implicit or none", config.TLS)
+	}
+
+	return &SMTPSender{config: config}, nil
+}
+
+func (s *SMTPSender) Send(email Email) (string, error) {
+	var message bytes.Buffer
+	if err := email.WriteMIME(&message); err != nil {
+		return "", err
+	}
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := s.dial()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	// net/smtp refuses to send credentials over an unencrypted connection
+	// unless the server is on localhost
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return "", fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return "", fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, address := range email.To {
+		to, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", err
+		}
+		if err := client.Rcpt(to.Address); err != nil {
+			return "", fmt.Errorf("SMTP RCPT TO %s failed: %w", to.Address, err)
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := data.Write(message.Bytes()); err != nil {
+		return "", fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return "", fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+
+	return "", client.Quit()
+}
+
+func (s *SMTPSender) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := &tls.Config{ServerName: s.config.Host}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if s.config.TLS == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// Bound the whole exchange so a stalled server can't hold up the outbox
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	if s.config.TLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,156 @@
+package emails
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// emailTemplate is an HTML body with its plain text alternative. Every
+// template has both, named <name>.html and <name>.txt, and they are rendered
+// from the same data. Templates hold no text of their own, they look it up
+// in the recipient's locale with {{t "key" .}}.
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// templateNames lists every template, each must have an embedded default.
+var templateNames = []string{
+	TemplateForgotPassword,
+	TemplateVerifyEmail,
+	TemplateAccountLocked,
+	TemplateConfirmEmailChange,
+	TemplateEmailChangeRequested,
+	TemplatePasswordChanged,
+	TemplateAccountExists,
+	TemplateAccountDeletionScheduled,
+	TemplateDataExportReady,
+}
+
+// templateFuncs stand in for the functions bound to a locale when a template
+// is rendered.
+var templateFuncs = map[string]any{
+	"t": func(key string, data any) (string, error) {
+		return "", errors.New("template rendered without a locale")
+	},
+	"locale": func() string { return DefaultLocale },
+}
+
+// Templates are the parsed email templates.
+type Templates struct {
+	templates map[string]emailTemplate
+}
+
+// LoadTemplates parses the embedded templates, using the file of the same
+// name in dir in place of any embedded one. dir may be empty, and files in it
+// that don't belong to a template are rejected so a misnamed override isn't
+// silently ignored.
+func LoadTemplates(dir string) (*Templates, error) {
+	embedded, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides fs.FS
+	if dir != "" {
+		overrides = os.DirFS(dir)
+		entries, err := fs.ReadDir(overrides, ".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email template directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			base, extension, _ := strings.Cut(name, ".")
+			if entry.IsDir() || !slices.Contains(templateNames, base) || (extension != "html" && extension != "txt") {
+				return nil, fmt.Errorf("unknown email template file %s", filepath.Join(dir, name))
+			}
+		}
+	}
+
+	templates := &Templates{templates: map[string]emailTemplate{}}
+	for _, name := range templateNames {
+		htmlSource, err := readTemplateFile(embedded, overrides, name+".html")
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New(name + ".html").Funcs(templateFuncs).Parse(htmlSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s html template: %w", name, err)
+		}
+
+		textSource, err := readTemplateFile(embedded, overrides, name+".txt")
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.New(name + ".txt").Funcs(templateFuncs).Parse(textSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template: %w", name, err)
+		}
+
+		templates.templates[name] = emailTemplate{html: html, text: text}
+	}
+
+	return templates, nil
+}
+
+func readTemplateFile(embedded fs.FS, overrides fs.FS, name string) (string, error) {
+	if overrides != nil {
+		data, err := fs.ReadFile(overrides, name)
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read email template %s: %w", name, err)
+		}
+	}
+
+	data, err := fs.ReadFile(embedded, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// render executes both templates with their text translated into locale.
+func (t emailTemplate) render(locales *Locales, locale string, data any) (html string, text string, err error) {
+	funcs := map[string]any{
+		"t": func(key string, data any) (string, error) {
+			return locales.translate(locale, key, data)
+		},
+		"locale": func() string { return locale },
+	}
+
+	// Templates are cloned because their functions can't be rebound per
+	// render without racing other deliveries
+	htmlTemplate, err := t.html.Clone()
+	if err != nil {
+		return "", "", err
+	}
+	textTemplate, err := t.text.Clone()
+	if err != nil {
+		return "", "", err
+	}
+
+	var htmlBuilder strings.Builder
+	if err := htmlTemplate.Funcs(funcs).Execute(&htmlBuilder, data); err != nil {
+		return "", "", fmt.Errorf("failed to execute %s: %w", t.html.Name(), err)
+	}
+
+	var textBuilder strings.Builder
+	if err := textTemplate.Funcs(funcs).Execute(&textBuilder, data); err != nil {
+		return "", "", fmt.Errorf("failed to execute %s: %w", t.text.Name(), err)
+	}
+
+	return htmlBuilder.String(), textBuilder.String(), nil
+}
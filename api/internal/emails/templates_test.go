@@ -0,0 +1,98 @@
+package emails
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEveryTemplateHasHTMLAndText(t *testing.T) {
+	names := map[string][]string{}
+	err := fs.WalkDir(templateFiles, "templates", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name := strings.TrimPrefix(path, "templates/")
+		base := name[:strings.LastIndex(name, ".")]
+		names[base] = append(names[base], name[len(base):])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for base, extensions := range names {
+		if len(extensions) != 2 || !slices.Contains(extensions, ".html") || !slices.Contains(extensions, ".txt") {
+			t.Errorf("template %s has %v, want exactly .html and .txt", base, extensions)
+		}
+		if !slices.Contains(templateNames, base) {
+			t.Errorf("template %s is not registered", base)
+		}
+	}
+}
+
+func TestTemplatesRenderWithSameData(t *testing.T) {
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locales, err := LoadLocales("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, locale := range locales.List() {
+		for _, name := range templateNames {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				testTemplateRenders(t, templates, locales, locale, name)
+			})
+		}
+	}
+}
+
+func testTemplateRenders(t *testing.T, templates *Templates, locales *Locales, locale string, name string) {
+	service, err := NewEmailService(nil, nil, templates, locales, "Example <no-reply@example.com>", "https://app.example.com", Branding{
+		ServiceName:      "Example",
+		SupportEmail:     "support@example.com",
+		LogoURL:          "https://app.example.com/logo.png",
+		PrimaryColor:     "#000000",
+		PrimaryTextColor: "#ffffff",
+		FooterAddress:    "1 Example Street",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewEmailService: %v", err)
+	}
+
+	subject, html, text, err := service.render(Message{
+		Template:    name,
+		To:          "user@example.com",
+		Username:    "someone",
+		Token:       "dG9rZW4=",
+		LockedUntil: time.Now().Add(time.Hour),
+		Locale:      locale,
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if subject == "" {
+		t.Error("subject is empty")
+	}
+	wants := []string{"someone", "1 Example Street"}
+	// Notices link to a page rather than carrying a token
+	if name != TemplatePasswordChanged && name != TemplateAccountExists {
+		wants = append(wants, "token=dG9rZW4=")
+	}
+	for body, content := range map[string]string{"html": html, "text": text} {
+		if strings.Contains(content, "<no value>") {
+			t.Errorf("%s body references missing data:\n%s", body, content)
+		}
+		for _, want := range wants {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s body does not contain %q:\n%s", body, want, content)
+			}
+		}
+	}
+}
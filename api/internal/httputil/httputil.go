@@ -16,6 +16,11 @@ func ParseBody(w http.ResponseWriter, r *http.Request, body any) error {
 }
 
 func HandleError(w http.ResponseWriter, err error) {
+	if derr, ok := err.(apperror.DetailedError); ok {
+		JSONResponse(w, derr.StatusCode(), derr.Body())
+		return
+	}
+
 	serr, ok := err.(apperror.HTTPError)
 	if ok {
 		http.Error(w, serr.Error(), serr.StatusCode())
@@ -26,6 +31,6 @@ func HandleError(w http.ResponseWriter, err error) {
 
 func JSONResponse(w http.ResponseWriter, status int, response any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
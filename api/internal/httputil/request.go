@@ -0,0 +1,108 @@
+package httputil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestMeta is the information about the caller that services record
+// alongside sessions and audit events, or use to pick defaults such as the
+// locale of a new account.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	// The raw Accept-Language header
+	AcceptLanguage string
+}
+
+func GetRequestMeta(r *http.Request) RequestMeta {
+	return RequestMeta{
+		IP:             ClientIP(r),
+		UserAgent:      r.UserAgent(),
+		RequestID:      middleware.GetReqID(r.Context()),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+	}
+}
+
+// ClientIP returns the address of the client, as set on the request by
+// RealIP.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// ParseTrustedProxies reads addresses and CIDR ranges of reverse proxies.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, expected an address or CIDR range", value)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RealIP sets the request's RemoteAddr to the client address. X-Forwarded-For
+// is only believed for requests arriving from a trusted proxy, since anyone
+// else can put what they like in it. Entries are read from the right, each
+// one added by the proxy before it, and the first that isn't itself a trusted
+// proxy is the client. With no trusted proxies the header is ignored.
+func RealIP(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, err := netip.ParseAddr(ClientIP(r))
+			if err != nil || len(trustedProxies) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			client = client.Unmap()
+			if !trusted(client) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var hops []string
+			for _, header := range r.Header.Values("X-Forwarded-For") {
+				hops = append(hops, strings.Split(header, ",")...)
+			}
+			for i := len(hops) - 1; i >= 0 && trusted(client); i-- {
+				hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = hop.Unmap()
+			}
+
+			r.RemoteAddr = client.String()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
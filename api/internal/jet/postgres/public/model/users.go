@@ -12,11 +12,20 @@ import (
 )
 
 type Users struct {
-	ID            []byte `sql:"primary_key"`
-	Email         string
-	Username      string
-	PasswordHash  string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	EmailVerified bool
+	ID                  []byte `sql:"primary_key"`
+	Email               string
+	Username            string
+	PasswordHash        string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	EmailVerified       bool
+	IsAdmin             bool
+	FailedLoginAttempts int32
+	LastFailedLoginAt   *time.Time
+	LockedUntil         *time.Time
+	Active              bool
+	ExternalID          *string
+	ScimTenantID        *[]byte
+	Locale              string
+	DeleteAfter         *time.Time
 }
@@ -10,8 +10,26 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	AccountDeletionTokens = AccountDeletionTokens.FromSchema(schema)
+	AccountTombstones = AccountTombstones.FromSchema(schema)
+	AccountUnlockTokens = AccountUnlockTokens.FromSchema(schema)
+	AuditEvents = AuditEvents.FromSchema(schema)
+	DataExports = DataExports.FromSchema(schema)
+	EmailChangeRequests = EmailChangeRequests.FromSchema(schema)
+	EmailMessages = EmailMessages.FromSchema(schema)
+	EmailSuppressions = EmailSuppressions.FromSchema(schema)
 	EmailVerificationTokens = EmailVerificationTokens.FromSchema(schema)
+	GroupMembers = GroupMembers.FromSchema(schema)
+	Groups = Groups.FromSchema(schema)
+	Outbox = Outbox.FromSchema(schema)
+	PasswordHistory = PasswordHistory.FromSchema(schema)
 	PasswordResetTokens = PasswordResetTokens.FromSchema(schema)
+	RateLimits = RateLimits.FromSchema(schema)
 	RefreshTokens = RefreshTokens.FromSchema(schema)
+	ScheduledJobs = ScheduledJobs.FromSchema(schema)
+	ScimTenants = ScimTenants.FromSchema(schema)
+	ScimTokens = ScimTokens.FromSchema(schema)
 	Users = Users.FromSchema(schema)
+	WebhookDeliveries = WebhookDeliveries.FromSchema(schema)
+	WebhookSubscriptions = WebhookSubscriptions.FromSchema(schema)
 }
@@ -17,13 +17,22 @@ type usersTable struct {
 	postgres.Table
 
 	// Columns
-	ID            postgres.ColumnBytea
-	Email         postgres.ColumnString
-	Username      postgres.ColumnString
-	PasswordHash  postgres.ColumnString
-	CreatedAt     postgres.ColumnTimestampz
-	UpdatedAt     postgres.ColumnTimestampz
-	EmailVerified postgres.ColumnBool
+	ID                  postgres.ColumnBytea
+	Email               postgres.ColumnString
+	Username            postgres.ColumnString
+	PasswordHash        postgres.ColumnString
+	CreatedAt           postgres.ColumnTimestampz
+	UpdatedAt           postgres.ColumnTimestampz
+	EmailVerified       postgres.ColumnBool
+	IsAdmin             postgres.ColumnBool
+	FailedLoginAttempts postgres.ColumnInteger
+	LastFailedLoginAt   postgres.ColumnTimestampz
+	LockedUntil         postgres.ColumnTimestampz
+	Active              postgres.ColumnBool
+	ExternalID          postgres.ColumnString
+	ScimTenantID        postgres.ColumnBytea
+	Locale              postgres.ColumnString
+	DeleteAfter         postgres.ColumnTimestampz
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -65,29 +74,47 @@ func newUsersTable(schemaName, tableName, alias string) *UsersTable {
 
 func newUsersTableImpl(schemaName, tableName, alias string) usersTable {
 	var (
-		IDColumn            = postgres.ByteaColumn("id")
-		EmailColumn         = postgres.StringColumn("email")
-		UsernameColumn      = postgres.StringColumn("username")
-		PasswordHashColumn  = postgres.StringColumn("password_hash")
-		CreatedAtColumn     = postgres.TimestampzColumn("created_at")
-		UpdatedAtColumn     = postgres.TimestampzColumn("updated_at")
-		EmailVerifiedColumn = postgres.BoolColumn("email_verified")
-		allColumns          = postgres.ColumnList{IDColumn, EmailColumn, UsernameColumn, PasswordHashColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedColumn}
-		mutableColumns      = postgres.ColumnList{EmailColumn, UsernameColumn, PasswordHashColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedColumn}
-		defaultColumns      = postgres.ColumnList{CreatedAtColumn, UpdatedAtColumn}
+		IDColumn                  = postgres.ByteaColumn("id")
+		EmailColumn               = postgres.StringColumn("email")
+		UsernameColumn            = postgres.StringColumn("username")
+		PasswordHashColumn        = postgres.StringColumn("password_hash")
+		CreatedAtColumn           = postgres.TimestampzColumn("created_at")
+		UpdatedAtColumn           = postgres.TimestampzColumn("updated_at")
+		EmailVerifiedColumn       = postgres.BoolColumn("email_verified")
+		IsAdminColumn             = postgres.BoolColumn("is_admin")
+		FailedLoginAttemptsColumn = postgres.IntegerColumn("failed_login_attempts")
+		LastFailedLoginAtColumn   = postgres.TimestampzColumn("last_failed_login_at")
+		LockedUntilColumn         = postgres.TimestampzColumn("locked_until")
+		ActiveColumn              = postgres.BoolColumn("active")
+		ExternalIDColumn          = postgres.StringColumn("external_id")
+		ScimTenantIDColumn        = postgres.ByteaColumn("scim_tenant_id")
+		LocaleColumn              = postgres.StringColumn("locale")
+		DeleteAfterColumn         = postgres.TimestampzColumn("delete_after")
+		allColumns                = postgres.ColumnList{IDColumn, EmailColumn, UsernameColumn, PasswordHashColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedColumn, IsAdminColumn, FailedLoginAttemptsColumn, LastFailedLoginAtColumn, LockedUntilColumn, ActiveColumn, ExternalIDColumn, ScimTenantIDColumn, LocaleColumn, DeleteAfterColumn}
+		mutableColumns            = postgres.ColumnList{EmailColumn, UsernameColumn, PasswordHashColumn, CreatedAtColumn, UpdatedAtColumn, EmailVerifiedColumn, IsAdminColumn, FailedLoginAttemptsColumn, LastFailedLoginAtColumn, LockedUntilColumn, ActiveColumn, ExternalIDColumn, ScimTenantIDColumn, LocaleColumn, DeleteAfterColumn}
+		defaultColumns            = postgres.ColumnList{CreatedAtColumn, UpdatedAtColumn, IsAdminColumn, FailedLoginAttemptsColumn, ActiveColumn, LocaleColumn}
 	)
 
 	return usersTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:            IDColumn,
-		Email:         EmailColumn,
-		Username:      UsernameColumn,
-		PasswordHash:  PasswordHashColumn,
-		CreatedAt:     CreatedAtColumn,
-		UpdatedAt:     UpdatedAtColumn,
-		EmailVerified: EmailVerifiedColumn,
+		ID:                  IDColumn,
+		Email:               EmailColumn,
+		Username:            UsernameColumn,
+		PasswordHash:        PasswordHashColumn,
+		CreatedAt:           CreatedAtColumn,
+		UpdatedAt:           UpdatedAtColumn,
+		EmailVerified:       EmailVerifiedColumn,
+		IsAdmin:             IsAdminColumn,
+		FailedLoginAttempts: FailedLoginAttemptsColumn,
+		LastFailedLoginAt:   LastFailedLoginAtColumn,
+		LockedUntil:         LockedUntilColumn,
+		Active:              ActiveColumn,
+		ExternalID:          ExternalIDColumn,
+		ScimTenantID:        ScimTenantIDColumn,
+		Locale:              LocaleColumn,
+		DeleteAfter:         DeleteAfterColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
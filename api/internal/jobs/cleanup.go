@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"auth/internal/repositories"
+	"time"
+)
+
+const cleanupBatchSize = 1000
+
+// TokenRetention is how long tokens are kept once they have expired or been
+// revoked, so that they can still be looked into, before they are deleted.
+type TokenRetention struct {
+	RefreshTokens           time.Duration
+	PasswordResetTokens     time.Duration
+	EmailVerificationTokens time.Duration
+}
+
+// RegisterTokenCleanup adds a job for each token table that deletes tokens
+// past their retention every interval.
+func (s *Scheduler) RegisterTokenCleanup(interval time.Duration, retention TokenRetention) {
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(s.db)
+	passwordResetTokenRepo := repositories.NewPasswordResetTokenRepository(s.db)
+	emailVerificationTokenRepo := repositories.NewEmailVerificationTokenRepository(s.db)
+
+	s.Register("delete-expired-refresh-tokens", interval, deleteExpired(refreshTokenRepo.DeleteExpired, retention.RefreshTokens))
+	s.Register("delete-expired-password-reset-tokens", interval, deleteExpired(passwordResetTokenRepo.DeleteExpired, retention.PasswordResetTokens))
+	s.Register("delete-expired-email-verification-tokens", interval, deleteExpired(emailVerificationTokenRepo.DeleteExpired, retention.EmailVerificationTokens))
+}
+
+// RegisterRateLimitCleanup adds a job that deletes rate limit counters whose
+// window has ended every interval. Such counters are reset on the next hit
+// anyway, so they are deleted as soon as they expire.
+func (s *Scheduler) RegisterRateLimitCleanup(interval time.Duration) {
+	rateLimitRepo := repositories.NewRateLimitRepository(s.db)
+
+	s.Register("delete-expired-rate-limits", interval, deleteExpired(rateLimitRepo.DeleteExpired, 0))
+}
+
+// deleteExpired deletes in batches, so that a large backlog doesn't hold
+// locks on the table for long.
+func deleteExpired(deleteBatch func(cutoff time.Time, limit int64) (int64, error), retention time.Duration) Func {
+	return func() (int64, error) {
+		cutoff := time.Now().Add(-retention)
+
+		var deleted int64
+		for {
+			n, err := deleteBatch(cutoff, cleanupBatchSize)
+			deleted += n
+			if err != nil || n < cleanupBatchSize {
+				return deleted, err
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/retry"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"time"
+)
+
+// leaderLockKey identifies the advisory lock held by the replica that runs
+// jobs. Nothing else in the database may use it.
+const leaderLockKey int64 = 0x617574686a6f6273 // "authjobs"
+
+// Func does one run of a job and returns how many items it processed.
+type Func func() (int64, error)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Scheduler runs periodic maintenance jobs. Every replica runs a scheduler,
+// but only the one holding a Postgres advisory lock runs jobs, so each job
+// runs once per interval however many replicas there are. If the leader goes
+// away its session ends, releasing the lock for another replica to take.
+type Scheduler struct {
+	db           *sql.DB
+	pollInterval time.Duration
+	jobs         []job
+
+	// The connection holding the lock, nil unless this replica is leader
+	leaderConn *sql.Conn
+
+	scheduledJobRepo repositories.ScheduledJobRepository
+}
+
+func NewScheduler(db *sql.DB, pollInterval time.Duration) *Scheduler {
+	return &Scheduler{
+		db:               db,
+		pollInterval:     pollInterval,
+		scheduledJobRepo: repositories.NewScheduledJobRepository(db),
+	}
+}
+
+// Register adds a job to run every interval. It must be called before Run.
+func (s *Scheduler) Register(name string, interval time.Duration, run Func) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Run checks for due jobs every poll interval until ctx is cancelled, running
+// them while this replica is leader.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	defer s.resign()
+
+	for {
+		s.runDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue runs each job whose interval has passed since its last run started,
+// going by the runs recorded by whichever replica was leader at the time.
+func (s *Scheduler) runDue(ctx context.Context) {
+	if !s.lead(ctx) {
+		return
+	}
+
+	records, err := s.scheduledJobRepo.List()
+	if err != nil {
+		return
+	}
+	lastStarted := map[string]time.Time{}
+	for _, record := range records {
+		if record.LastStartedAt != nil {
+			lastStarted[record.Name] = *record.LastStartedAt
+		}
+	}
+
+	for _, job := range s.jobs {
+		if started, ok := lastStarted[job.name]; ok && time.Since(started) < job.interval {
+			continue
+		}
+		// Leadership may have been lost while the previous job ran
+		if ctx.Err() != nil || !s.lead(ctx) {
+			return
+		}
+		s.runJob(job)
+	}
+}
+
+func (s *Scheduler) runJob(job job) {
+	if err := s.scheduledJobRepo.RecordStart(job.name, time.Now()); err != nil {
+		return
+	}
+
+	processed, err := job.run()
+
+	var lastError *string
+	if err != nil {
+		log.Printf("[ERROR] Job %s failed: %v", job.name, err)
+		message := retry.ErrorMessage(err)
+		lastError = &message
+	}
+	s.scheduledJobRepo.RecordFinish(job.name, time.Now(), processed, lastError)
+}
+
+// lead reports whether this replica is leader, taking the lock if no replica
+// holds it.
+func (s *Scheduler) lead(ctx context.Context) bool {
+	if s.leaderConn != nil {
+		err := s.leaderConn.PingContext(ctx)
+		if err == nil {
+			return true
+		}
+		log.Printf("[WARN] Job scheduler lost its leader connection: %v", err)
+		s.resign()
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		log.Printf("[ERROR] Job scheduler failed to get a connection: %v", err)
+		return false
+	}
+
+	advisoryLockRepo := repositories.NewAdvisoryLockRepository(conn)
+	locked, err := advisoryLockRepo.TryLock(leaderLockKey)
+	if err != nil || !locked {
+		conn.Close()
+		return false
+	}
+
+	s.leaderConn = conn
+	return true
+}
+
+// resign gives up leadership by discarding the connection holding the lock
+// rather than returning it to the pool, which ends its session and so
+// releases the lock even if the connection is in a bad state.
+func (s *Scheduler) resign() {
+	if s.leaderConn == nil {
+		return
+	}
+	// Returning ErrBadConn closes the Conn and discards the driver connection
+	s.leaderConn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+	s.leaderConn = nil
+}
+
+type Status struct {
+	Name            string `json:"name"`
+	IntervalSeconds int64  `json:"interval_seconds"`
+	// running, succeeded or failed, nil if the job has never run
+	Status         *string    `json:"status"`
+	LastStartedAt  *time.Time `json:"last_started_at"`
+	LastFinishedAt *time.Time `json:"last_finished_at"`
+	LastProcessed  *int64     `json:"last_processed"`
+	LastError      *string    `json:"last_error"`
+	// When the job is next due, nil if it is due now
+	NextRunAt *time.Time `json:"next_run_at"`
+}
+
+// Status returns the last run and outcome of each registered job, as
+// recorded by whichever replica ran it.
+func (s *Scheduler) Status() ([]Status, error) {
+	records, err := s.scheduledJobRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]model.ScheduledJobs{}
+	for _, record := range records {
+		byName[record.Name] = record
+	}
+
+	statuses := make([]Status, len(s.jobs))
+	for i, job := range s.jobs {
+		status := Status{Name: job.name, IntervalSeconds: int64(job.interval / time.Second)}
+
+		record, ok := byName[job.name]
+		if ok && record.LastStartedAt != nil {
+			status.Status = record.LastStatus
+			status.LastStartedAt = record.LastStartedAt
+			status.LastFinishedAt = record.LastFinishedAt
+			status.LastProcessed = record.LastProcessed
+			status.LastError = record.LastError
+			if record.LastFinishedAt == nil || record.LastFinishedAt.Before(*record.LastStartedAt) {
+				running := "running"
+				status.Status = &running
+			}
+			if nextRunAt := record.LastStartedAt.Add(job.interval); nextRunAt.After(time.Now()) {
+				status.NextRunAt = &nextRunAt
+			}
+		}
+
+		statuses[i] = status
+	}
+
+	return statuses, nil
+}
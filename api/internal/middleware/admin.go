@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"auth/internal/auth"
+	"auth/internal/repositories"
+	"net/http"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// RequireAdmin must be mounted after Auth. The admin flag is read from the
+// database on every request so that revoking it takes effect immediately.
+func RequireAdmin(userRepo repositories.UserRepository) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := r.Context().Value(AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(claims.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			user, err := userRepo.GetByID(userID)
+			if err != nil || !user.IsAdmin {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -10,7 +10,19 @@ import (
 
 const AuthContextKey = "jwtClaims"
 
+// Auth stores the *auth.AccessClaims of the request's bearer token in the
+// request context. Tokens restricted to auth.ScopeVerifyEmail are refused.
 func Auth(publicKey ed25519.PublicKey, issuer string) func(next http.Handler) http.Handler {
+	return authenticate(publicKey, issuer, false)
+}
+
+// AuthRestricted is Auth for the endpoints users need to verify their email
+// address, which also accept tokens restricted to auth.ScopeVerifyEmail.
+func AuthRestricted(publicKey ed25519.PublicKey, issuer string) func(next http.Handler) http.Handler {
+	return authenticate(publicKey, issuer, true)
+}
+
+func authenticate(publicKey ed25519.PublicKey, issuer string, allowRestricted bool) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -23,13 +35,25 @@ func Auth(publicKey ed25519.PublicKey, issuer string) func(next http.Handler) ht
 				http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
 				return
 			}
-			_, claims, err := auth.ValidateToken(publicKey, token)
+			claims, err := auth.ValidateAccessToken(publicKey, token)
 			if err != nil {
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
-			if err = auth.ValidateClaims(claims, issuer); err != nil {
+			if err = auth.ValidateClaims(&claims.RegisteredClaims, issuer); err != nil {
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			switch claims.Scope {
+			case "":
+			case auth.ScopeVerifyEmail:
+				if !allowRestricted {
+					http.Error(w, "Email address not verified", http.StatusForbidden)
+					return
+				}
+			default:
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
 			}
 
 			ctx := context.WithValue(r.Context(), AuthContextKey, claims)
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/auth"
+	"auth/internal/httputil"
+	"net/http"
+	"time"
+)
+
+// RequireRecentAuth must be mounted after Auth. It guards sensitive
+// operations by refusing tokens whose user last proved who they are more than
+// maxAge ago, with an error code telling the client to reauthenticate.
+func RequireRecentAuth(maxAge time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := r.Context().Value(AuthContextKey).(*auth.AccessClaims)
+			if !claims.AuthenticatedWithin(maxAge) {
+				httputil.HandleError(w, apperror.NewReauthenticationRequired())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
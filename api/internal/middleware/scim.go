@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"auth/internal/auth"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"context"
+	"net/http"
+	"strings"
+)
+
+const ScimTenantContextKey = "scimTenantID"
+
+// ScimAuth authenticates identity providers by the bearer token issued to
+// their tenant and stores the tenant's ID in the request context.
+func ScimAuth(scimTokenRepo repositories.ScimTokenRepository) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			token := strings.TrimPrefix(authHeader, "Bearer ")
+			if authHeader == "" || token == authHeader {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			decoded, err := auth.URLDecodeToken(token)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			scimToken, err := scimTokenRepo.GetActiveByHash(auth.HashToken(decoded))
+			if err != nil || scimToken == nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			scimTokenRepo.Touch(ulidutil.MustFromBytes(scimToken.ID))
+
+			ctx := context.WithValue(r.Context(), ScimTenantContextKey, ulidutil.MustFromBytes(scimToken.TenantID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
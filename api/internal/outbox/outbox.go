@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/retry"
+	"auth/internal/ulidutil"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// How long a claimed message is hidden from other workers. Handlers should
+// finish well within it or the message may be handled twice.
+const claimLease = 5 * time.Minute
+
+type Config struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	BatchSize    int
+}
+
+// Handler delivers a message's payload. Returning an error schedules a
+// retry, so handlers must tolerate seeing the same message more than once.
+// The message ID stays the same across attempts and can serve as an
+// idempotency key.
+type Handler func(messageID ulid.ULID, payload []byte) error
+
+// Outbox holds side effects such as emails and webhook events that must only
+// happen if the change that caused them is committed. They are written in the
+// same transaction as that change and handed to the handler registered for
+// their kind once it commits, retrying with exponential backoff on failure.
+type Outbox struct {
+	handlers map[string]Handler
+	worker   *retry.Worker[model.Outbox]
+
+	outboxRepo repositories.OutboxRepository
+}
+
+func NewOutbox(db *sql.DB, config Config) *Outbox {
+	o := &Outbox{
+		handlers:   map[string]Handler{},
+		outboxRepo: repositories.NewOutboxRepository(db),
+	}
+	o.worker = retry.NewWorker(retry.Config{
+		PollInterval: config.PollInterval,
+		MaxAttempts:  config.MaxAttempts,
+		BatchSize:    config.BatchSize,
+		Lease:        claimLease,
+	}, o.outboxRepo.ClaimDue, o.process)
+	return o
+}
+
+// Handle registers the handler for a kind of message. It must be called
+// before Run.
+func (o *Outbox) Handle(kind string, handler Handler) {
+	o.handlers[kind] = handler
+}
+
+// Enqueue writes a message through db, which should be the transaction making
+// the change the message belongs to.
+func (o *Outbox) Enqueue(db repositories.DB, kind string, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s outbox message: %w", kind, err)
+	}
+
+	now := time.Now()
+	outboxRepo := repositories.NewOutboxRepository(db)
+	return outboxRepo.Create(model.Outbox{
+		ID:            ulid.Make().Bytes(),
+		Kind:          kind,
+		Payload:       string(encoded),
+		Status:        repositories.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+// Run handles due messages until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	o.worker.Run(ctx)
+}
+
+func (o *Outbox) process(_ context.Context, message model.Outbox) {
+	messageID := ulidutil.MustFromBytes(message.ID)
+
+	handler, ok := o.handlers[message.Kind]
+	if !ok {
+		o.outboxRepo.RecordFailure(messageID, repositories.OutboxFailed, "No handler registered for "+message.Kind, time.Now())
+		return
+	}
+
+	err := handler(messageID, []byte(message.Payload))
+	if err == nil {
+		o.outboxRepo.MarkProcessed(messageID)
+		return
+	}
+
+	lastError := retry.ErrorMessage(err)
+	nextAttemptAt, ok := o.worker.NextAttempt(message.Attempts)
+	if !ok {
+		log.Printf("[ERROR] Outbox %s message %s failed after %d attempts: %s", message.Kind, messageID, message.Attempts, lastError)
+		o.outboxRepo.RecordFailure(messageID, repositories.OutboxFailed, lastError, time.Now())
+		return
+	}
+
+	o.outboxRepo.RecordFailure(messageID, repositories.OutboxPending, lastError, nextAttemptAt)
+}
@@ -0,0 +1,133 @@
+package passwordhash
+
+import (
+	"auth/internal/apperror"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Verifier checks a password against hashes of a single format.
+type Verifier interface {
+	Verify(password string, hash string) (bool, error)
+}
+
+// Config holds the argon2id cost parameters. Zero values fall back to the
+// library defaults.
+type Config struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type registration struct {
+	prefix   string
+	verifier Verifier
+}
+
+// Hasher creates argon2id hashes and verifies passwords against any
+// registered format, picking the verifier by the hash's prefix.
+type Hasher struct {
+	params    *argon2id.Params
+	verifiers []registration
+	// Compared against when there is no account, see CompareDummy
+	dummyHash string
+}
+
+// NewHasher returns a Hasher for the current argon2id parameters that also
+// verifies the bcrypt and PBKDF2-SHA256 hashes imported from legacy systems.
+func NewHasher(config Config) *Hasher {
+	params := *argon2id.DefaultParams
+	if config.Memory != 0 {
+		params.Memory = config.Memory
+	}
+	if config.Iterations != 0 {
+		params.Iterations = config.Iterations
+	}
+	if config.Parallelism != 0 {
+		params.Parallelism = config.Parallelism
+	}
+
+	h := &Hasher{params: &params}
+	h.dummyHash, _ = h.Hash("dummy password")
+
+	h.Register(argon2idPrefix, argon2idVerifier{})
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		h.Register(prefix, bcryptVerifier{})
+	}
+	h.Register("$pbkdf2-sha256$", passlibPBKDF2Verifier{})
+	h.Register("pbkdf2_sha256$", djangoPBKDF2Verifier{})
+
+	return h
+}
+
+// Register adds a verifier for hashes starting with prefix.
+func (h *Hasher) Register(prefix string, verifier Verifier) {
+	h.verifiers = append(h.verifiers, registration{prefix: prefix, verifier: verifier})
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	hash, err := argon2id.CreateHash(password, h.params)
+	if err != nil {
+		return "", apperror.NewInternalServerError("Internal server error")
+	}
+	return hash, nil
+}
+
+// Compare reports whether password matches hash. Hashes in an unrecognised or
+// malformed format never match.
+func (h *Hasher) Compare(password string, hash string) bool {
+	verifier := h.lookup(hash)
+	if verifier == nil {
+		return false
+	}
+
+	match, err := verifier.Verify(password, hash)
+	if err != nil {
+		return false
+	}
+	return match
+}
+
+// CompareDummy does the work of a Compare that fails, so that a request for
+// an account that doesn't exist takes as long as one with a wrong password.
+func (h *Hasher) CompareDummy(password string) {
+	h.Compare(password, h.dummyHash)
+}
+
+// Recognizes reports whether hash is in a format some verifier understands.
+func (h *Hasher) Recognizes(hash string) bool {
+	return h.lookup(hash) != nil
+}
+
+// NeedsRehash reports whether hash should be replaced by a fresh one, either
+// because it is in a legacy format or because it was created with weaker
+// argon2id parameters than the current ones.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return true
+	}
+
+	params, _, _, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return true
+	}
+
+	// Parallelism changes the output but not the cost to an attacker
+	return params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.SaltLength < h.params.SaltLength ||
+		params.KeyLength < h.params.KeyLength
+}
+
+func (h *Hasher) lookup(hash string) Verifier {
+	for _, registration := range h.verifiers {
+		if strings.HasPrefix(hash, registration.prefix) {
+			return registration.verifier
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package passwordhash
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errMalformedHash = errors.New("malformed password hash")
+
+type argon2idVerifier struct{}
+
+func (argon2idVerifier) Verify(password string, hash string) (bool, error) {
+	return argon2id.ComparePasswordAndHash(password, hash)
+}
+
+// bcryptVerifier handles the $2a$, $2b$ and $2y$ variants, which only differ
+// in how historic implementations handled bugs the Go implementation never had.
+type bcryptVerifier struct{}
+
+func (bcryptVerifier) Verify(password string, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// passlibPBKDF2Verifier handles the modular crypt format used by passlib,
+// $pbkdf2-sha256$<iterations>$<salt>$<key>, where salt and key use base64
+// with "." in place of "+" and no padding.
+type passlibPBKDF2Verifier struct{}
+
+func (passlibPBKDF2Verifier) Verify(password string, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 {
+		return false, errMalformedHash
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations < 1 {
+		return false, errMalformedHash
+	}
+
+	salt, err := decodeAdaptedBase64(parts[3])
+	if err != nil {
+		return false, errMalformedHash
+	}
+	key, err := decodeAdaptedBase64(parts[4])
+	if err != nil || len(key) == 0 {
+		return false, errMalformedHash
+	}
+
+	return comparePBKDF2(password, salt, iterations, key)
+}
+
+// djangoPBKDF2Verifier handles Django's pbkdf2_sha256$<iterations>$<salt>$<key>
+// format, where the salt is used as is and the key is standard base64.
+type djangoPBKDF2Verifier struct{}
+
+func (djangoPBKDF2Verifier) Verify(password string, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 {
+		return false, errMalformedHash
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false, errMalformedHash
+	}
+
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return false, errMalformedHash
+	}
+
+	return comparePBKDF2(password, []byte(parts[2]), iterations, key)
+}
+
+func comparePBKDF2(password string, salt []byte, iterations int, key []byte) (bool, error) {
+	derived, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(key))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(derived, key) == 1, nil
+}
+
+func decodeAdaptedBase64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(s, ".", "+"))
+}
@@ -0,0 +1,189 @@
+package passwordpolicy
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/breach"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
+)
+
+type Config struct {
+	MinLength   int
+	MaxLength   int
+	MinScore    int
+	HistorySize int
+	// Passwords seen in breaches at least this many times are rejected, and
+	// those seen fewer times only produce a warning
+	BreachRejectMinCount int
+}
+
+// Policy is applied to every new password, whether it is set at
+// registration, through a reset or by changing it while signed in.
+type Policy struct {
+	config        Config
+	compareHash   func(password string, hash string) bool
+	breachChecker breach.Checker
+
+	passwordHistoryRepo repositories.PasswordHistoryRepository
+}
+
+// NewPolicy takes compareHash to check candidates against previous password
+// hashes without depending on the hashing implementation. breachChecker may
+// be nil to skip breach screening.
+func NewPolicy(db *sql.DB, config Config, compareHash func(password string, hash string) bool, breachChecker breach.Checker) *Policy {
+	return &Policy{
+		config:              config,
+		compareHash:         compareHash,
+		breachChecker:       breachChecker,
+		passwordHistoryRepo: repositories.NewPasswordHistoryRepository(db),
+	}
+}
+
+// WarningsResponse is returned in place of an empty response when a password
+// was accepted with warnings.
+type WarningsResponse struct {
+	Warnings []apperror.Violation `json:"warnings"`
+}
+
+// Candidate is a new password along with the account it is for. User is nil
+// when the account does not exist yet.
+type Candidate struct {
+	Password string
+	Username string
+	Email    string
+	User     *model.Users
+}
+
+// Validate returns a 422 listing every rule the password breaks. A password
+// that passes may still come back with warnings worth showing the user.
+func (p *Policy) Validate(candidate Candidate) ([]apperror.Violation, error) {
+	var violations, warnings []apperror.Violation
+
+	length := utf8.RuneCountInString(candidate.Password)
+	if length < p.config.MinLength {
+		violations = append(violations, apperror.Violation{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("Password must be at least %d characters", p.config.MinLength),
+		})
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violations = append(violations, apperror.Violation{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("Password must be at most %d characters", p.config.MaxLength),
+		})
+		// Don't spend time estimating or hashing oversized input
+		return nil, apperror.NewValidationError("Password does not meet requirements", violations)
+	}
+
+	lower := strings.ToLower(candidate.Password)
+	username := strings.ToLower(strings.TrimSpace(candidate.Username))
+	if len(username) >= minPatternLength && strings.Contains(lower, username) {
+		violations = append(violations, apperror.Violation{
+			Rule:    "contains_username",
+			Message: "Password must not contain your username",
+		})
+	}
+	email := strings.ToLower(strings.TrimSpace(candidate.Email))
+	localPart, _, _ := strings.Cut(email, "@")
+	if len(localPart) >= minPatternLength && strings.Contains(lower, localPart) {
+		violations = append(violations, apperror.Violation{
+			Rule:    "contains_email",
+			Message: "Password must not contain your email address",
+		})
+	}
+
+	if score := EstimateStrength(candidate.Password, username, email, localPart); score < p.config.MinScore {
+		violations = append(violations, apperror.Violation{
+			Rule:    "strength",
+			Message: fmt.Sprintf("Password is too easy to guess (strength %d of 4, at least %d required)", score, p.config.MinScore),
+		})
+	}
+
+	if p.breachChecker != nil {
+		count, err := p.breachChecker.Count(candidate.Password)
+		if err != nil {
+			// Fail open, a broken corpus should not block every password change
+			log.Printf("[ERROR] Breached password check failed: %v", err)
+		} else if count > 0 {
+			breached := apperror.Violation{
+				Rule:    "breached",
+				Message: "Password has appeared in a data breach and is unsafe to use",
+			}
+			if count >= p.config.BreachRejectMinCount {
+				violations = append(violations, breached)
+			} else {
+				warnings = append(warnings, breached)
+			}
+		}
+	}
+
+	if candidate.User != nil && p.config.HistorySize > 0 {
+		reused, err := p.isReused(candidate.Password, candidate.User)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			violations = append(violations, apperror.Violation{
+				Rule:    "reused",
+				Message: fmt.Sprintf("Password must differ from your last %d passwords", p.config.HistorySize),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, apperror.NewValidationError("Password does not meet requirements", violations)
+	}
+	return warnings, nil
+}
+
+// Remember records a newly set password hash so it can't be reused, keeping
+// only as many entries as the history check looks at. db should be the
+// transaction that sets the password.
+func (p *Policy) Remember(db repositories.DB, userID ulid.ULID, passwordHash string) error {
+	if p.config.HistorySize <= 0 {
+		return nil
+	}
+
+	entry := model.PasswordHistory{
+		ID:           ulid.Make().Bytes(),
+		UserID:       userID.Bytes(),
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db)
+	if err := passwordHistoryRepo.Create(entry); err != nil {
+		return err
+	}
+
+	return passwordHistoryRepo.Prune(userID, int64(p.config.HistorySize))
+}
+
+func (p *Policy) isReused(password string, user *model.Users) (bool, error) {
+	// Accounts created before history was kept only have their current hash
+	if p.compareHash(password, user.PasswordHash) {
+		return true, nil
+	}
+
+	userID := ulidutil.MustFromBytes(user.ID)
+	entries, err := p.passwordHistoryRepo.ListRecent(userID, int64(p.config.HistorySize))
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.PasswordHash != user.PasswordHash && p.compareHash(password, entry.PasswordHash) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,279 @@
+package passwordpolicy
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+//go:embed common-passwords.txt
+var commonPasswordsList string
+
+// commonPasswords maps each common password to its popularity rank, starting at 1.
+var commonPasswords = func() map[string]int {
+	ranks := make(map[string]int)
+	for i, password := range strings.Fields(commonPasswordsList) {
+		password = strings.ToLower(password)
+		if _, ok := ranks[password]; !ok {
+			ranks[password] = i + 1
+		}
+	}
+	return ranks
+}()
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i',
+	'!': 'i', '|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+const (
+	minPatternLength = 3
+	minKeyboardRun   = 4
+)
+
+// EstimateStrength scores a password from 0 (trivially guessable) to 4 (very
+// hard to guess) in the manner of zxcvbn. The password is split into the
+// cheapest sequence of recognisable patterns, dictionary words, sequences,
+// repeats and keyboard runs, falling back to brute force for anything left
+// over, and the score is derived from the resulting number of guesses.
+// userInputs such as the username are treated as the most likely words.
+func EstimateStrength(password string, userInputs ...string) int {
+	return scoreFromGuesses(estimateGuesses(password, userInputs))
+}
+
+func scoreFromGuesses(log10Guesses float64) int {
+	switch {
+	case log10Guesses < 3:
+		return 0
+	case log10Guesses < 6:
+		return 1
+	case log10Guesses < 8:
+		return 2
+	case log10Guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// estimateGuesses returns log10 of the minimum number of guesses needed to
+// find password, using dynamic programming over every pattern match.
+func estimateGuesses(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 0
+	}
+
+	dictionary := make(map[string]int, len(userInputs))
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if len([]rune(input)) >= minPatternLength {
+			dictionary[input] = 1
+		}
+	}
+
+	// best[i] is the cheapest way to guess runes[:i]. patterns counts the
+	// multi-character matches used, since joining many cheap patterns together
+	// still costs the attacker something.
+	best := make([]float64, n+1)
+	patterns := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(1)
+	}
+
+	for end := 1; end <= n; end++ {
+		for start := 0; start < end; start++ {
+			if math.IsInf(best[start], 1) {
+				continue
+			}
+
+			cost := matchGuesses(runes[start:end], dictionary)
+			if math.IsInf(cost, 1) {
+				continue
+			}
+
+			total := best[start] + cost
+			if total < best[end] {
+				best[end] = total
+				patterns[end] = patterns[start]
+				if end-start > 1 {
+					patterns[end]++
+				}
+			}
+		}
+	}
+
+	return best[n] + math.Log10(factorial(patterns[n]))
+}
+
+// matchGuesses returns log10 of the guesses needed for token as a single
+// pattern, or +Inf if token matches nothing but is longer than one character.
+func matchGuesses(token []rune, dictionary map[string]int) float64 {
+	if len(token) == 1 {
+		return math.Log10(float64(cardinality(token[0])))
+	}
+
+	guesses := math.Inf(1)
+	if g, ok := dictionaryGuesses(token, dictionary); ok {
+		guesses = min(guesses, g)
+	}
+	if len(token) >= minPatternLength {
+		if g, ok := repeatGuesses(token); ok {
+			guesses = min(guesses, g)
+		}
+		if g, ok := sequenceGuesses(token); ok {
+			guesses = min(guesses, g)
+		}
+	}
+	if len(token) >= minKeyboardRun {
+		if g, ok := keyboardGuesses(token); ok {
+			guesses = min(guesses, g)
+		}
+	}
+	if isDigits(token) && len(token) <= 8 {
+		guesses = min(guesses, dateOrNumberGuesses(token))
+	}
+
+	return guesses
+}
+
+func dictionaryGuesses(token []rune, dictionary map[string]int) (float64, bool) {
+	lower := strings.ToLower(string(token))
+	unleet := unleetString(lower)
+
+	rank, ok := dictionary[lower]
+	if !ok {
+		rank, ok = commonPasswords[lower]
+	}
+	leet := false
+	if !ok && unleet != lower {
+		if rank, ok = dictionary[unleet]; !ok {
+			rank, ok = commonPasswords[unleet]
+		}
+		leet = ok
+	}
+	if !ok {
+		return 0, false
+	}
+
+	guesses := math.Log10(float64(rank))
+	if lower != string(token) {
+		guesses += math.Log10(2)
+	}
+	if leet {
+		guesses += math.Log10(2)
+	}
+	return guesses, true
+}
+
+func repeatGuesses(token []rune) (float64, bool) {
+	for _, r := range token[1:] {
+		if r != token[0] {
+			return 0, false
+		}
+	}
+	return math.Log10(float64(cardinality(token[0]) * len(token))), true
+}
+
+func sequenceGuesses(token []rune) (float64, bool) {
+	delta := token[1] - token[0]
+	if delta != 1 && delta != -1 {
+		return 0, false
+	}
+	for i := 2; i < len(token); i++ {
+		if token[i]-token[i-1] != delta {
+			return 0, false
+		}
+	}
+
+	base := 26
+	if unicode.IsDigit(token[0]) {
+		base = 10
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return math.Log10(float64(base * len(token))), true
+}
+
+func keyboardGuesses(token []rune) (float64, bool) {
+	lower := strings.ToLower(string(token))
+	reversed := reverse(lower)
+	for _, row := range keyboardRows {
+		if strings.Contains(row, lower) || strings.Contains(row, reversed) {
+			return math.Log10(float64(40 * len(token))), true
+		}
+	}
+	return 0, false
+}
+
+// dateOrNumberGuesses treats short digit strings as years, dates or PINs,
+// which attackers try long before other numbers of the same length.
+func dateOrNumberGuesses(token []rune) float64 {
+	if len(token) == 4 && (token[0] == '1' && token[1] == '9' || token[0] == '2' && token[1] == '0') {
+		return math.Log10(120)
+	}
+	if len(token) == 6 || len(token) == 8 {
+		return math.Log10(365 * 100)
+	}
+	return float64(len(token))
+}
+
+func cardinality(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r):
+		return 26
+	case unicode.IsUpper(r):
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func unleetString(s string) string {
+	return strings.Map(func(r rune) rune {
+		if sub, ok := leetSubstitutions[r]; ok {
+			return sub
+		}
+		return r
+	}, s)
+}
+
+func isDigits(token []rune) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func factorial(n int) float64 {
+	result := 1.0
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+	return result
+}
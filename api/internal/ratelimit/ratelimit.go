@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/httputil"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPeekBodyBytes = 1 << 20
+
+type Config struct {
+	Window     time.Duration
+	IPLimit    int
+	EmailLimit int
+	PairLimit  int
+}
+
+type Limiter struct {
+	store  Store
+	config Config
+}
+
+func NewLimiter(store Store, config Config) *Limiter {
+	return &Limiter{store: store, config: config}
+}
+
+type rule struct {
+	key   string
+	limit int
+}
+
+type result struct {
+	limit     int
+	remaining int
+	resetAt   time.Time
+	exceeded  bool
+}
+
+// Middleware limits requests to an endpoint by client IP, by the email field
+// of the JSON body, and by the combination of both. Counters are namespaced by
+// name so each endpoint has its own budget. Requests without an email are
+// limited by IP only.
+func (l *Limiter) Middleware(name string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := httputil.ClientIP(r)
+			email := peekEmail(r)
+
+			rules := []rule{{key: name + ":ip:" + ip, limit: l.config.IPLimit}}
+			if email != "" {
+				rules = append(rules,
+					rule{key: name + ":email:" + email, limit: l.config.EmailLimit},
+					rule{key: name + ":pair:" + ip + ":" + email, limit: l.config.PairLimit},
+				)
+			}
+
+			// Report the rule closest to being exhausted, or the first one that is
+			var reported *result
+			for _, rule := range rules {
+				if rule.limit <= 0 {
+					continue
+				}
+
+				count, resetAt, err := l.store.Hit(rule.key, l.config.Window)
+				if err != nil {
+					// Fail open, an unavailable store should not take logins down with it
+					log.Printf("[ERROR] Rate limit hit failed for %s: %v", rule.key, err)
+					continue
+				}
+
+				res := result{
+					limit:     rule.limit,
+					remaining: max(rule.limit-count, 0),
+					resetAt:   resetAt,
+					exceeded:  count > rule.limit,
+				}
+				if reported == nil || res.exceeded || res.remaining < reported.remaining {
+					reported = &res
+				}
+				if res.exceeded {
+					break
+				}
+			}
+
+			if reported == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resetIn := strconv.Itoa(int(math.Ceil(time.Until(reported.resetAt).Seconds())))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(reported.limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(reported.remaining))
+			w.Header().Set("RateLimit-Reset", resetIn)
+
+			if reported.exceeded {
+				w.Header().Set("Retry-After", resetIn)
+				httputil.HandleError(w, apperror.NewTooManyRequests(""))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// peekEmail reads the email field from a JSON body and restores the body so
+// the handler can parse it again.
+func peekEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBodyBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var fields struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(fields.Email))
+}
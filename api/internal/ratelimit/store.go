@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"auth/internal/repositories"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Store counts hits per key in fixed windows.
+type Store interface {
+	// Hit records a hit against key and returns the number of hits in the
+	// current window along with when that window resets.
+	Hit(key string, window time.Duration) (count int, resetAt time.Time, err error)
+}
+
+type memoryEntry struct {
+	count   int
+	resetAt time.Time
+}
+
+// MemoryStore keeps counters in process. Limits are per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Hit(key string, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, entry := range s.entries {
+			if !entry.resetAt.After(now) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.resetAt.After(now) {
+		entry = &memoryEntry{resetAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+	entry.count++
+
+	return entry.count, entry.resetAt, nil
+}
+
+// PostgresStore keeps counters in the rate_limits table so that limits hold
+// across replicas.
+type PostgresStore struct {
+	rateLimitRepo repositories.RateLimitRepository
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{rateLimitRepo: repositories.NewRateLimitRepository(db)}
+}
+
+func (s *PostgresStore) Hit(key string, window time.Duration) (int, time.Time, error) {
+	limit, err := s.rateLimitRepo.Increment(key, window)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(limit.Count), limit.ResetAt, nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type AccountDeletionTokenRepository struct {
+	db DB
+}
+
+func NewAccountDeletionTokenRepository(db DB) AccountDeletionTokenRepository {
+	return AccountDeletionTokenRepository{db: db}
+}
+
+func (r *AccountDeletionTokenRepository) Create(token model.AccountDeletionTokens) error {
+	_, err := AccountDeletionTokens.INSERT().MODEL(token).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create account deletion token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *AccountDeletionTokenRepository) GetByHash(hash []byte) (*model.AccountDeletionTokens, error) {
+	query := AccountDeletionTokens.SELECT(AccountDeletionTokens.AllColumns).
+		WHERE(AccountDeletionTokens.TokenHash.EQ(Bytea(hash))).
+		LIMIT(1)
+
+	var tokens []model.AccountDeletionTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] GetByHash query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(tokens) == 0 {
+		return nil, apperror.NewNotFound("Token not found")
+	}
+
+	return &tokens[0], nil
+}
+
+func (r *AccountDeletionTokenRepository) Revoke(id ulid.ULID) error {
+	_, err := AccountDeletionTokens.UPDATE().
+		SET(AccountDeletionTokens.RevokedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(AccountDeletionTokens.ID.EQ(Bytea(id.Bytes())), AccountDeletionTokens.RevokedAt.IS_NULL())).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Revoke account deletion token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *AccountDeletionTokenRepository) RevokeByUserID(userID ulid.ULID) error {
+	_, err := AccountDeletionTokens.UPDATE().
+		SET(AccountDeletionTokens.RevokedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(AccountDeletionTokens.UserID.EQ(Bytea(userID.Bytes())), AccountDeletionTokens.RevokedAt.IS_NULL())).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Revoke account deletion tokens by userID failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
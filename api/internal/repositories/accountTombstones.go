@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"crypto/sha256"
+	"log"
+	"strings"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+const (
+	AccountTombstoneEmail    = "email"
+	AccountTombstoneUsername = "username"
+)
+
+// AccountTombstoneRepository reserves the emails and usernames of deleted
+// accounts for a while, so that nobody can take them over and receive what
+// was meant for the previous owner. Values are stored hashed, since the point
+// of deleting the account was to forget them, and compared case
+// insensitively.
+type AccountTombstoneRepository struct {
+	db DB
+}
+
+func NewAccountTombstoneRepository(db DB) AccountTombstoneRepository {
+	return AccountTombstoneRepository{db: db}
+}
+
+func tombstoneHash(kind string, value string) []byte {
+	hash := sha256.Sum256([]byte(kind + ":" + strings.ToLower(value)))
+	return hash[:]
+}
+
+// Create reserves value until expiresAt, extending an existing reservation.
+func (r *AccountTombstoneRepository) Create(kind string, value string, expiresAt time.Time) error {
+	_, err := AccountTombstones.INSERT().
+		MODEL(model.AccountTombstones{
+			ValueHash: tombstoneHash(kind, value),
+			Kind:      kind,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now(),
+		}).
+		ON_CONFLICT(AccountTombstones.ValueHash).
+		DO_UPDATE(SET(
+			AccountTombstones.ExpiresAt.SET(AccountTombstones.EXCLUDED.ExpiresAt),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create account tombstone failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *AccountTombstoneRepository) Exists(kind string, value string) (bool, error) {
+	query := AccountTombstones.SELECT(AccountTombstones.ValueHash).
+		WHERE(AND(
+			AccountTombstones.ValueHash.EQ(Bytea(tombstoneHash(kind, value))),
+			AccountTombstones.ExpiresAt.GT(TimestampzT(time.Now())),
+		)).
+		LIMIT(1)
+
+	var tombstones []model.AccountTombstones
+	err := query.Query(r.db, &tombstones)
+	if err != nil {
+		log.Printf("[ERROR] Account tombstone query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(tombstones) > 0, nil
+}
+
+func (r *AccountTombstoneRepository) DeleteExpired() (int64, error) {
+	result, err := AccountTombstones.DELETE().
+		WHERE(AccountTombstones.ExpiresAt.LT_EQ(TimestampzT(time.Now()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete expired account tombstones failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete expired account tombstones failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted, nil
+}
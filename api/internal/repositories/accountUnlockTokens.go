@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type AccountUnlockTokenRepository struct {
+	db DB
+}
+
+func NewAccountUnlockTokenRepository(db DB) AccountUnlockTokenRepository {
+	return AccountUnlockTokenRepository{db: db}
+}
+
+func (r *AccountUnlockTokenRepository) Create(token model.AccountUnlockTokens) error {
+	_, err := AccountUnlockTokens.INSERT().MODEL(token).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create account unlock token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *AccountUnlockTokenRepository) GetByHash(hash []byte) (*model.AccountUnlockTokens, error) {
+	query := AccountUnlockTokens.SELECT(AccountUnlockTokens.AllColumns).
+		WHERE(AccountUnlockTokens.TokenHash.EQ(Bytea(hash))).
+		LIMIT(1)
+
+	var tokens []model.AccountUnlockTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] GetByHash query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(tokens) == 0 {
+		return nil, apperror.NewNotFound("Token not found")
+	}
+
+	return &tokens[0], nil
+}
+
+func (r *AccountUnlockTokenRepository) Revoke(id ulid.ULID) error {
+	_, err := AccountUnlockTokens.UPDATE().
+		SET(AccountUnlockTokens.RevokedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(AccountUnlockTokens.ID.EQ(Bytea(id.Bytes())), AccountUnlockTokens.RevokedAt.IS_NULL())).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Revoke account unlock token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *AccountUnlockTokenRepository) RevokeByUserID(userID ulid.ULID) error {
+	_, err := AccountUnlockTokens.UPDATE().
+		SET(AccountUnlockTokens.RevokedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(AccountUnlockTokens.UserID.EQ(Bytea(userID.Bytes())), AccountUnlockTokens.RevokedAt.IS_NULL())).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Revoke account unlock tokens by userID failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
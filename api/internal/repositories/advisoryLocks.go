@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"log"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+// AdvisoryLockRepository takes Postgres session-level advisory locks. They
+// belong to the connection they were taken on, so it must be built on a
+// dedicated *sql.Conn rather than the pool. A lock is held until that
+// connection's session ends.
+type AdvisoryLockRepository struct {
+	db DB
+}
+
+func NewAdvisoryLockRepository(db DB) AdvisoryLockRepository {
+	return AdvisoryLockRepository{db: db}
+}
+
+// TryLock takes the lock without waiting and reports whether it got it.
+func (r *AdvisoryLockRepository) TryLock(key int64) (bool, error) {
+	query := SELECT(BoolExp(Raw("pg_try_advisory_lock(#key)", RawArgs{"#key": key})).AS("locked"))
+
+	var result struct {
+		Locked bool `alias:"locked"`
+	}
+	err := query.Query(r.db, &result)
+	if err != nil {
+		log.Printf("[ERROR] Try advisory lock failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return result.Locked, nil
+}
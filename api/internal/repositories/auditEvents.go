@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type AuditEventRepository struct {
+	db DB
+}
+
+func NewAuditEventRepository(db DB) AuditEventRepository {
+	return AuditEventRepository{db: db}
+}
+
+func (r *AuditEventRepository) Create(event model.AuditEvents) error {
+	_, err := AuditEvents.INSERT().MODEL(event).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create audit event failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+type AuditEventFilter struct {
+	UserID     *ulid.ULID
+	EventTypes []string
+	From       *time.Time
+	To         *time.Time
+	Before     *ulid.ULID
+	Limit      int64
+}
+
+// List returns matching events newest first. Before is an exclusive cursor on
+// the event ID, which sorts by creation time since IDs are ULIDs.
+func (r *AuditEventRepository) List(filter AuditEventFilter) ([]model.AuditEvents, error) {
+	conditions := []BoolExpression{Bool(true)}
+	if filter.UserID != nil {
+		conditions = append(conditions, AuditEvents.UserID.EQ(Bytea(filter.UserID.Bytes())))
+	}
+	if len(filter.EventTypes) > 0 {
+		types := make([]Expression, len(filter.EventTypes))
+		for i, t := range filter.EventTypes {
+			types[i] = String(t)
+		}
+		conditions = append(conditions, AuditEvents.EventType.IN(types...))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, AuditEvents.CreatedAt.GT_EQ(TimestampzT(*filter.From)))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, AuditEvents.CreatedAt.LT(TimestampzT(*filter.To)))
+	}
+	if filter.Before != nil {
+		conditions = append(conditions, AuditEvents.ID.LT(Bytea(filter.Before.Bytes())))
+	}
+
+	query := AuditEvents.SELECT(AuditEvents.AllColumns).
+		WHERE(AND(conditions...)).
+		ORDER_BY(AuditEvents.ID.DESC()).
+		LIMIT(filter.Limit)
+
+	var events []model.AuditEvents
+	err := query.Query(r.db, &events)
+	if err != nil {
+		log.Printf("[ERROR] List audit events query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return events, nil
+}
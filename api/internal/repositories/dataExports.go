@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	DataExportPending    = "pending"
+	DataExportReady      = "ready"
+	DataExportDownloaded = "downloaded"
+)
+
+// DataExportRepository holds the personal data archives users ask for. An
+// archive is kept only until it is downloaded or its link expires.
+type DataExportRepository struct {
+	db DB
+}
+
+func NewDataExportRepository(db DB) DataExportRepository {
+	return DataExportRepository{db: db}
+}
+
+func (r *DataExportRepository) Create(export model.DataExports) error {
+	_, err := DataExports.INSERT().MODEL(export).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create data export failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *DataExportRepository) GetByID(id ulid.ULID) (*model.DataExports, error) {
+	query := DataExports.SELECT(DataExports.AllColumns).
+		WHERE(DataExports.ID.EQ(Bytea(id.Bytes()))).
+		LIMIT(1)
+
+	var exports []model.DataExports
+	err := query.Query(r.db, &exports)
+	if err != nil {
+		log.Printf("[ERROR] GetByID query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	return &exports[0], nil
+}
+
+// GetActiveByUserID returns the user's export that is still being assembled
+// or waiting to be downloaded, if any. Exports requested before pendingSince
+// that never completed are treated as abandoned.
+func (r *DataExportRepository) GetActiveByUserID(userID ulid.ULID, pendingSince time.Time) (*model.DataExports, error) {
+	query := DataExports.SELECT(DataExports.AllColumns).
+		WHERE(AND(
+			DataExports.UserID.EQ(Bytea(userID.Bytes())),
+			OR(
+				AND(DataExports.Status.EQ(String(DataExportPending)), DataExports.CreatedAt.GT(TimestampzT(pendingSince))),
+				AND(DataExports.Status.EQ(String(DataExportReady)), DataExports.ExpiresAt.GT(TimestampzT(time.Now()))),
+			),
+		)).
+		ORDER_BY(DataExports.CreatedAt.DESC()).
+		LIMIT(1)
+
+	var exports []model.DataExports
+	err := query.Query(r.db, &exports)
+	if err != nil {
+		log.Printf("[ERROR] Active data export query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	return &exports[0], nil
+}
+
+// Complete stores the archive of a pending export along with the hash of its
+// download token. It reports whether the export was still pending.
+func (r *DataExportRepository) Complete(id ulid.ULID, archive []byte, tokenHash []byte, expiresAt time.Time) (bool, error) {
+	result, err := DataExports.UPDATE(DataExports.Status, DataExports.Archive, DataExports.TokenHash, DataExports.ExpiresAt, DataExports.CompletedAt).
+		SET(
+			DataExports.Status.SET(String(DataExportReady)),
+			DataExports.Archive.SET(Bytea(archive)),
+			DataExports.TokenHash.SET(Bytea(tokenHash)),
+			DataExports.ExpiresAt.SET(TimestampzT(expiresAt)),
+			DataExports.CompletedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(AND(DataExports.ID.EQ(Bytea(id.Bytes())), DataExports.Status.EQ(String(DataExportPending)))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Complete data export failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Complete data export failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return updated > 0, nil
+}
+
+// ClaimDownload marks the user's export with the given token hash as
+// downloaded and returns it, or nil if there is no such export or its link
+// was already used or has expired. Only one caller can claim an export.
+func (r *DataExportRepository) ClaimDownload(userID ulid.ULID, tokenHash []byte) (*model.DataExports, error) {
+	query := DataExports.UPDATE(DataExports.Status, DataExports.DownloadedAt).
+		SET(
+			DataExports.Status.SET(String(DataExportDownloaded)),
+			DataExports.DownloadedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(AND(
+			DataExports.TokenHash.EQ(Bytea(tokenHash)),
+			DataExports.UserID.EQ(Bytea(userID.Bytes())),
+			DataExports.Status.EQ(String(DataExportReady)),
+			DataExports.ExpiresAt.GT(TimestampzT(time.Now())),
+		)).
+		RETURNING(DataExports.AllColumns)
+
+	var exports []model.DataExports
+	err := query.Query(r.db, &exports)
+	if err != nil {
+		log.Printf("[ERROR] Claim data export download failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	return &exports[0], nil
+}
+
+// DiscardArchive drops the archive of an export, keeping the record of it.
+func (r *DataExportRepository) DiscardArchive(id ulid.ULID) error {
+	_, err := DataExports.UPDATE(DataExports.Archive).
+		SET(DataExports.Archive.SET(ByteaExp(NULL))).
+		WHERE(DataExports.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Discard data export archive failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// DiscardExpired drops the archives of exports whose link expired unused and
+// returns how many it dropped.
+func (r *DataExportRepository) DiscardExpired() (int64, error) {
+	result, err := DataExports.UPDATE(DataExports.Archive).
+		SET(DataExports.Archive.SET(ByteaExp(NULL))).
+		WHERE(AND(DataExports.Archive.IS_NOT_NULL(), DataExports.ExpiresAt.LT_EQ(TimestampzT(time.Now())))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Discard expired data export archives failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	discarded, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Discard expired data export archives failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return discarded, nil
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type EmailChangeRequestRepository struct {
+	db DB
+}
+
+func NewEmailChangeRequestRepository(db DB) EmailChangeRequestRepository {
+	return EmailChangeRequestRepository{db: db}
+}
+
+func (r *EmailChangeRequestRepository) Create(request model.EmailChangeRequests) error {
+	_, err := EmailChangeRequests.INSERT().MODEL(request).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create email change request failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// GetByConfirmHash returns the request the confirmation token belongs to, or
+// nil.
+func (r *EmailChangeRequestRepository) GetByConfirmHash(hash []byte) (*model.EmailChangeRequests, error) {
+	return r.getWhere(EmailChangeRequests.ConfirmTokenHash.EQ(Bytea(hash)))
+}
+
+// GetByCancelHash returns the request the cancellation token belongs to, or
+// nil.
+func (r *EmailChangeRequestRepository) GetByCancelHash(hash []byte) (*model.EmailChangeRequests, error) {
+	return r.getWhere(EmailChangeRequests.CancelTokenHash.EQ(Bytea(hash)))
+}
+
+// GetPendingByUserID returns the user's unexpired request that has been
+// neither confirmed nor cancelled, or nil.
+func (r *EmailChangeRequestRepository) GetPendingByUserID(userID ulid.ULID) (*model.EmailChangeRequests, error) {
+	return r.getWhere(AND(
+		EmailChangeRequests.UserID.EQ(Bytea(userID.Bytes())),
+		EmailChangeRequests.ConfirmedAt.IS_NULL(),
+		EmailChangeRequests.CancelledAt.IS_NULL(),
+		EmailChangeRequests.ExpiresAt.GT(TimestampzT(time.Now())),
+	))
+}
+
+// ListByUserID returns all of the user's requests, oldest first.
+func (r *EmailChangeRequestRepository) ListByUserID(userID ulid.ULID) ([]model.EmailChangeRequests, error) {
+	query := EmailChangeRequests.SELECT(EmailChangeRequests.AllColumns).
+		WHERE(EmailChangeRequests.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(EmailChangeRequests.ID.ASC())
+
+	var requests []model.EmailChangeRequests
+	err := query.Query(r.db, &requests)
+	if err != nil {
+		log.Printf("[ERROR] List email change requests by userID failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return requests, nil
+}
+
+func (r *EmailChangeRequestRepository) getWhere(condition BoolExpression) (*model.EmailChangeRequests, error) {
+	query := EmailChangeRequests.SELECT(EmailChangeRequests.AllColumns).
+		WHERE(condition).
+		ORDER_BY(EmailChangeRequests.ID.DESC()).
+		LIMIT(1)
+
+	var requests []model.EmailChangeRequests
+	err := query.Query(r.db, &requests)
+	if err != nil {
+		log.Printf("[ERROR] Get email change request query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	return &requests[0], nil
+}
+
+// MarkConfirmed reports false if the request was confirmed or cancelled in
+// the meantime.
+func (r *EmailChangeRequestRepository) MarkConfirmed(id ulid.ULID) (bool, error) {
+	result, err := EmailChangeRequests.UPDATE().
+		SET(EmailChangeRequests.ConfirmedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(
+			EmailChangeRequests.ID.EQ(Bytea(id.Bytes())),
+			EmailChangeRequests.ConfirmedAt.IS_NULL(),
+			EmailChangeRequests.CancelledAt.IS_NULL(),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Confirm email change request failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Confirm email change request failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return updated > 0, nil
+}
+
+// MarkCancelled reports false if the request was already cancelled.
+func (r *EmailChangeRequestRepository) MarkCancelled(id ulid.ULID) (bool, error) {
+	result, err := EmailChangeRequests.UPDATE().
+		SET(EmailChangeRequests.CancelledAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(
+			EmailChangeRequests.ID.EQ(Bytea(id.Bytes())),
+			EmailChangeRequests.CancelledAt.IS_NULL(),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Cancel email change request failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Cancel email change request failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return updated > 0, nil
+}
+
+// CancelPendingByUserID cancels the user's outstanding requests, so that only
+// the latest one can be confirmed.
+func (r *EmailChangeRequestRepository) CancelPendingByUserID(userID ulid.ULID) error {
+	_, err := EmailChangeRequests.UPDATE().
+		SET(EmailChangeRequests.CancelledAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(
+			EmailChangeRequests.UserID.EQ(Bytea(userID.Bytes())),
+			EmailChangeRequests.ConfirmedAt.IS_NULL(),
+			EmailChangeRequests.CancelledAt.IS_NULL(),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Cancel pending email change requests failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
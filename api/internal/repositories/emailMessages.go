@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	EmailMessageSent       = "sent"
+	EmailMessageSuppressed = "suppressed"
+	EmailMessageDelivered  = "delivered"
+	EmailMessageOpened     = "opened"
+	EmailMessageBounced    = "bounced"
+	EmailMessageComplained = "complained"
+)
+
+type EmailMessageRepository struct {
+	db DB
+}
+
+func NewEmailMessageRepository(db DB) EmailMessageRepository {
+	return EmailMessageRepository{db: db}
+}
+
+// Create records a send. Recording the same message again does nothing, so
+// a retried delivery keeps its original record.
+func (r *EmailMessageRepository) Create(message model.EmailMessages) error {
+	_, err := EmailMessages.INSERT().MODEL(message).ON_CONFLICT(EmailMessages.ID).DO_NOTHING().Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create email message failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// ListByUserID returns the messages sent to or about the user, oldest first.
+func (r *EmailMessageRepository) ListByUserID(userID ulid.ULID) ([]model.EmailMessages, error) {
+	query := EmailMessages.SELECT(EmailMessages.AllColumns).
+		WHERE(EmailMessages.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(EmailMessages.ID.ASC())
+
+	var messages []model.EmailMessages
+	err := query.Query(r.db, &messages)
+	if err != nil {
+		log.Printf("[ERROR] List email messages by userID failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return messages, nil
+}
+
+// AdvanceStatus sets the status of the message the provider knows by
+// providerMessageID, but only while its status is one of from, so events
+// arriving out of order can't move a message backwards. It returns the
+// updated message, or nil if nothing changed.
+func (r *EmailMessageRepository) AdvanceStatus(providerMessageID string, status string, from []string, detail *string) (*model.EmailMessages, error) {
+	fromExpressions := make([]Expression, len(from))
+	for i, s := range from {
+		fromExpressions[i] = String(s)
+	}
+	detailExp := StringExpression(EmailMessages.Detail)
+	if detail != nil {
+		detailExp = String(*detail)
+	}
+
+	query := EmailMessages.UPDATE(EmailMessages.Status, EmailMessages.Detail, EmailMessages.UpdatedAt).
+		SET(
+			EmailMessages.Status.SET(String(status)),
+			EmailMessages.Detail.SET(detailExp),
+			EmailMessages.UpdatedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(AND(
+			EmailMessages.ProviderMessageID.EQ(String(providerMessageID)),
+			EmailMessages.Status.IN(fromExpressions...),
+		)).
+		RETURNING(EmailMessages.AllColumns)
+
+	var messages []model.EmailMessages
+	err := query.Query(r.db, &messages)
+	if err != nil {
+		log.Printf("[ERROR] Advance email message status failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	return &messages[0], nil
+}
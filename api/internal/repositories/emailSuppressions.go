@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"strings"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+const (
+	EmailSuppressionHardBounce = "hard_bounce"
+)
+
+// EmailSuppressionRepository holds addresses that must not be mailed.
+// Addresses are compared case insensitively.
+type EmailSuppressionRepository struct {
+	db DB
+}
+
+func NewEmailSuppressionRepository(db DB) EmailSuppressionRepository {
+	return EmailSuppressionRepository{db: db}
+}
+
+// Create suppresses an address, keeping the original reason if it already is.
+func (r *EmailSuppressionRepository) Create(suppression model.EmailSuppressions) error {
+	suppression.Address = strings.ToLower(suppression.Address)
+	_, err := EmailSuppressions.INSERT().MODEL(suppression).ON_CONFLICT(EmailSuppressions.Address).DO_NOTHING().Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create email suppression failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *EmailSuppressionRepository) Exists(address string) (bool, error) {
+	query := EmailSuppressions.SELECT(EmailSuppressions.Address).
+		WHERE(EmailSuppressions.Address.EQ(String(strings.ToLower(address)))).
+		LIMIT(1)
+
+	var suppressions []model.EmailSuppressions
+	err := query.Query(r.db, &suppressions)
+	if err != nil {
+		log.Printf("[ERROR] Email suppression query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(suppressions) > 0, nil
+}
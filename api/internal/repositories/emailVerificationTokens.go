@@ -4,7 +4,6 @@ import (
 	"auth/internal/apperror"
 	"auth/internal/jet/postgres/public/model"
 	. "auth/internal/jet/postgres/public/table"
-	"database/sql"
 	"log"
 	"time"
 
@@ -13,10 +12,10 @@ import (
 )
 
 type EmailVerificationTokenRepository struct {
-	db *sql.DB
+	db DB
 }
 
-func NewEmailVerificationTokenRepository(db *sql.DB) EmailVerificationTokenRepository {
+func NewEmailVerificationTokenRepository(db DB) EmailVerificationTokenRepository {
 	return EmailVerificationTokenRepository{db: db}
 }
 
@@ -48,6 +47,26 @@ func (r *EmailVerificationTokenRepository) GetByHash(hash []byte) (*model.EmailV
 	return &tokens[0], nil
 }
 
+// ListCreatedSince returns the user's tokens created after since, newest
+// first, whether or not they have been used or revoked.
+func (r *EmailVerificationTokenRepository) ListCreatedSince(userID ulid.ULID, since time.Time) ([]model.EmailVerificationTokens, error) {
+	query := EmailVerificationTokens.SELECT(EmailVerificationTokens.AllColumns).
+		WHERE(AND(
+			EmailVerificationTokens.UserID.EQ(Bytea(userID.Bytes())),
+			EmailVerificationTokens.CreatedAt.GT(TimestampzT(since)),
+		)).
+		ORDER_BY(EmailVerificationTokens.CreatedAt.DESC())
+
+	var tokens []model.EmailVerificationTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] List email verification tokens query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return tokens, nil
+}
+
 func (r *EmailVerificationTokenRepository) Revoke(id ulid.ULID) error {
 	_, err := EmailVerificationTokens.UPDATE().
 		SET(EmailVerificationTokens.RevokedAt.SET(TimestampzT(time.Now()))).
@@ -71,3 +90,28 @@ func (r *EmailVerificationTokenRepository) RevokeByUserID(userID ulid.ULID) erro
 	}
 	return nil
 }
+
+// DeleteExpired deletes up to limit email verification tokens that expired or were revoked before
+// cutoff and returns how many it deleted.
+func (r *EmailVerificationTokenRepository) DeleteExpired(cutoff time.Time, limit int64) (int64, error) {
+	expired := EmailVerificationTokens.SELECT(EmailVerificationTokens.ID).
+		WHERE(OR(
+			EmailVerificationTokens.ExpiresAt.LT(TimestampzT(cutoff)),
+			EmailVerificationTokens.RevokedAt.LT(TimestampzT(cutoff)),
+		)).
+		LIMIT(limit)
+
+	result, err := EmailVerificationTokens.DELETE().WHERE(EmailVerificationTokens.ID.IN(expired)).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete expired email verification tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete expired email verification tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted, nil
+}
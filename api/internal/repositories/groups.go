@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"auth/internal/scim/filter"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type GroupRepository struct {
+	db DB
+}
+
+func NewGroupRepository(db DB) GroupRepository {
+	return GroupRepository{db: db}
+}
+
+func (r *GroupRepository) Create(group model.Groups) error {
+	_, err := Groups.INSERT().MODEL(group).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create group failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// GetByID returns the group if it belongs to the tenant.
+func (r *GroupRepository) GetByID(tenantID ulid.ULID, id ulid.ULID) (*model.Groups, error) {
+	query := Groups.SELECT(Groups.AllColumns).
+		WHERE(AND(
+			Groups.ID.EQ(Bytea(id.Bytes())),
+			Groups.TenantID.EQ(Bytea(tenantID.Bytes())),
+		)).
+		LIMIT(1)
+
+	var groups []model.Groups
+	err := query.Query(r.db, &groups)
+	if err != nil {
+		log.Printf("[ERROR] GetByID query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(groups) == 0 {
+		return nil, apperror.NewNotFound("Group not found")
+	}
+
+	return &groups[0], nil
+}
+
+// GetByIDForUpdate is GetByID that also locks the group's row until the
+// transaction r was built on ends.
+func (r *GroupRepository) GetByIDForUpdate(tenantID ulid.ULID, id ulid.ULID) (*model.Groups, error) {
+	query := Groups.SELECT(Groups.AllColumns).
+		WHERE(AND(
+			Groups.ID.EQ(Bytea(id.Bytes())),
+			Groups.TenantID.EQ(Bytea(tenantID.Bytes())),
+		)).
+		LIMIT(1).
+		FOR(UPDATE())
+
+	var groups []model.Groups
+	err := query.Query(r.db, &groups)
+	if err != nil {
+		log.Printf("[ERROR] GetByIDForUpdate query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(groups) == 0 {
+		return nil, apperror.NewNotFound("Group not found")
+	}
+
+	return &groups[0], nil
+}
+
+// WillConflict reports whether another group in the tenant has the same
+// display name.
+func (r *GroupRepository) WillConflict(group model.Groups) (bool, error) {
+	query := Groups.SELECT(Groups.ID).
+		WHERE(AND(
+			Groups.TenantID.EQ(Bytea(group.TenantID)),
+			Groups.DisplayName.EQ(String(group.DisplayName)),
+			Groups.ID.NOT_EQ(Bytea(group.ID)),
+		)).
+		LIMIT(1)
+
+	var groups []model.Groups
+	err := query.Query(r.db, &groups)
+	if err != nil {
+		log.Printf("[ERROR] Will conflict query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(groups) > 0, nil
+}
+
+// List returns a page of the tenant's groups matching the filter along with
+// the total number of matches.
+func (r *GroupRepository) List(tenantID ulid.ULID, expr filter.Expression, offset int64, limit int64) ([]model.Groups, int64, error) {
+	condition, err := compileScimFilter(expr, scimGroupAttributes)
+	if err != nil {
+		return nil, 0, err
+	}
+	condition = AND(Groups.TenantID.EQ(Bytea(tenantID.Bytes())), condition)
+
+	var count struct {
+		Count int64 `alias:"count"`
+	}
+	err = SELECT(COUNT(STAR).AS("count")).FROM(Groups).WHERE(condition).Query(r.db, &count)
+	if err != nil {
+		log.Printf("[ERROR] Count groups query failed: %v", err)
+		return nil, 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	query := Groups.SELECT(Groups.AllColumns).
+		WHERE(condition).
+		ORDER_BY(Groups.ID.ASC()).
+		OFFSET(offset).
+		LIMIT(limit)
+
+	var groups []model.Groups
+	err = query.Query(r.db, &groups)
+	if err != nil {
+		log.Printf("[ERROR] List groups query failed: %v", err)
+		return nil, 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return groups, count.Count, nil
+}
+
+func (r *GroupRepository) Update(group model.Groups) error {
+	group.UpdatedAt = time.Now()
+	_, err := Groups.UPDATE(Groups.DisplayName, Groups.ExternalID, Groups.UpdatedAt).
+		MODEL(group).
+		WHERE(Groups.ID.EQ(Bytea(group.ID))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Update group failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *GroupRepository) Delete(id ulid.ULID) error {
+	_, err := Groups.DELETE().WHERE(Groups.ID.EQ(Bytea(id.Bytes()))).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete group failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+type GroupMember struct {
+	GroupID  []byte
+	UserID   []byte
+	Username string
+}
+
+// ListMembers returns the members of each of the given groups.
+func (r *GroupRepository) ListMembers(groupIDs ...ulid.ULID) ([]GroupMember, error) {
+	if len(groupIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]Expression, len(groupIDs))
+	for i, id := range groupIDs {
+		ids[i] = Bytea(id.Bytes())
+	}
+
+	query := SELECT(GroupMembers.GroupID.AS("group_member.group_id"), GroupMembers.UserID.AS("group_member.user_id"), Users.Username.AS("group_member.username")).
+		FROM(GroupMembers.INNER_JOIN(Users, Users.ID.EQ(GroupMembers.UserID))).
+		WHERE(GroupMembers.GroupID.IN(ids...)).
+		ORDER_BY(GroupMembers.CreatedAt.ASC())
+
+	var members []GroupMember
+	err := query.Query(r.db, &members)
+	if err != nil {
+		log.Printf("[ERROR] List group members query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return members, nil
+}
+
+// ListByMember returns the groups the user belongs to.
+func (r *GroupRepository) ListByMember(userID ulid.ULID) ([]model.Groups, error) {
+	query := SELECT(Groups.AllColumns).
+		FROM(Groups.INNER_JOIN(GroupMembers, GroupMembers.GroupID.EQ(Groups.ID))).
+		WHERE(GroupMembers.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(Groups.DisplayName.ASC())
+
+	var groups []model.Groups
+	err := query.Query(r.db, &groups)
+	if err != nil {
+		log.Printf("[ERROR] List groups by member query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return groups, nil
+}
+
+func (r *GroupRepository) AddMembers(groupID ulid.ULID, userIDs []ulid.ULID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	members := make([]model.GroupMembers, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = model.GroupMembers{GroupID: groupID.Bytes(), UserID: userID.Bytes(), CreatedAt: now}
+	}
+
+	_, err := GroupMembers.INSERT(GroupMembers.AllColumns).MODELS(members).ON_CONFLICT().DO_NOTHING().Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Add group members failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *GroupRepository) RemoveMembers(groupID ulid.ULID, userIDs []ulid.ULID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]Expression, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = Bytea(id.Bytes())
+	}
+
+	_, err := GroupMembers.DELETE().
+		WHERE(AND(GroupMembers.GroupID.EQ(Bytea(groupID.Bytes())), GroupMembers.UserID.IN(ids...))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Remove group members failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *GroupRepository) RemoveAllMembers(groupID ulid.ULID) error {
+	_, err := GroupMembers.DELETE().
+		WHERE(GroupMembers.GroupID.EQ(Bytea(groupID.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Remove all group members failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	OutboxPending   = "pending"
+	OutboxProcessed = "processed"
+	OutboxFailed    = "failed"
+)
+
+type OutboxRepository struct {
+	db DB
+}
+
+func NewOutboxRepository(db DB) OutboxRepository {
+	return OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Create(message model.Outbox) error {
+	_, err := Outbox.INSERT().MODEL(message).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create outbox message failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+var outboxQueue = retryQueue{
+	table:         Outbox,
+	columns:       Outbox.AllColumns,
+	pending:       OutboxPending,
+	id:            Outbox.ID,
+	status:        Outbox.Status,
+	attempts:      Outbox.Attempts,
+	nextAttemptAt: Outbox.NextAttemptAt,
+}
+
+// ClaimDue picks up to limit pending messages whose next attempt is due and
+// counts the attempt, hiding them from other workers for lease.
+func (r *OutboxRepository) ClaimDue(limit int64, lease time.Duration) ([]model.Outbox, error) {
+	var messages []model.Outbox
+	err := claimDue(r.db, outboxQueue, limit, lease, &messages)
+	if err != nil {
+		log.Printf("[ERROR] Claim outbox messages query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return messages, nil
+}
+
+// MarkProcessed settles a message. The payload is cleared since it can hold
+// single-use tokens that have no reason to outlive delivery.
+func (r *OutboxRepository) MarkProcessed(id ulid.ULID) error {
+	_, err := Outbox.UPDATE().
+		SET(
+			Outbox.Status.SET(String(OutboxProcessed)),
+			Outbox.Payload.SET(StringExp(Raw("'{}'::jsonb"))),
+			Outbox.LastError.SET(StringExp(NULL)),
+			Outbox.ProcessedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(Outbox.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Mark outbox message processed failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// RecordFailure stores a failed attempt. status is pending when the message
+// will be retried at nextAttemptAt.
+func (r *OutboxRepository) RecordFailure(id ulid.ULID, status string, lastError string, nextAttemptAt time.Time) error {
+	_, err := Outbox.UPDATE().
+		SET(
+			Outbox.Status.SET(String(status)),
+			Outbox.LastError.SET(String(lastError)),
+			Outbox.NextAttemptAt.SET(TimestampzT(nextAttemptAt)),
+		).
+		WHERE(Outbox.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Record outbox failure failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type PasswordHistoryRepository struct {
+	db DB
+}
+
+func NewPasswordHistoryRepository(db DB) PasswordHistoryRepository {
+	return PasswordHistoryRepository{db: db}
+}
+
+func (r *PasswordHistoryRepository) Create(entry model.PasswordHistory) error {
+	_, err := PasswordHistory.INSERT().MODEL(entry).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create password history entry failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// ListRecent returns the user's most recent password hashes, newest first.
+func (r *PasswordHistoryRepository) ListRecent(userID ulid.ULID, limit int64) ([]model.PasswordHistory, error) {
+	query := PasswordHistory.SELECT(PasswordHistory.AllColumns).
+		WHERE(PasswordHistory.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(PasswordHistory.ID.DESC()).
+		LIMIT(limit)
+
+	var entries []model.PasswordHistory
+	err := query.Query(r.db, &entries)
+	if err != nil {
+		log.Printf("[ERROR] List password history query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return entries, nil
+}
+
+// Prune deletes all but the user's keep most recent entries.
+func (r *PasswordHistoryRepository) Prune(userID ulid.ULID, keep int64) error {
+	recent := PasswordHistory.SELECT(PasswordHistory.ID).
+		WHERE(PasswordHistory.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(PasswordHistory.ID.DESC()).
+		LIMIT(keep)
+
+	_, err := PasswordHistory.DELETE().
+		WHERE(AND(
+			PasswordHistory.UserID.EQ(Bytea(userID.Bytes())),
+			PasswordHistory.ID.NOT_IN(recent),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Prune password history failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"auth/internal/apperror"
 	"auth/internal/jet/postgres/public/model"
 	. "auth/internal/jet/postgres/public/table"
-	"database/sql"
 	"log"
 	"time"
 
@@ -13,10 +12,10 @@ import (
 )
 
 type PasswordResetTokenRepository struct {
-	db *sql.DB
+	db DB
 }
 
-func NewPasswordResetTokenRepository(db *sql.DB) PasswordResetTokenRepository {
+func NewPasswordResetTokenRepository(db DB) PasswordResetTokenRepository {
 	return PasswordResetTokenRepository{db: db}
 }
 
@@ -48,16 +47,25 @@ func (r *PasswordResetTokenRepository) GetByHash(hash []byte) (*model.PasswordRe
 	return &tokens[0], nil
 }
 
-func (r *PasswordResetTokenRepository) Revoke(id ulid.ULID) error {
-	_, err := PasswordResetTokens.UPDATE().
+// Revoke reports whether the token was still unused, so that of two
+// concurrent resets with the same token only one goes through.
+func (r *PasswordResetTokenRepository) Revoke(id ulid.ULID) (bool, error) {
+	result, err := PasswordResetTokens.UPDATE().
 		SET(PasswordResetTokens.RevokedAt.SET(TimestampzT(time.Now()))).
 		WHERE(AND(PasswordResetTokens.ID.EQ(Bytea(id.Bytes())), PasswordResetTokens.RevokedAt.IS_NULL())).
 		Exec(r.db)
 	if err != nil {
 		log.Printf("[ERROR] Revoke password reset token failed: %v", err)
-		return apperror.NewInternalServerError("Database query error")
+		return false, apperror.NewInternalServerError("Database query error")
 	}
-	return nil
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Revoke password reset token failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return revoked > 0, nil
 }
 
 func (r *PasswordResetTokenRepository) RevokeByUserID(userID ulid.ULID) error {
@@ -71,3 +79,28 @@ func (r *PasswordResetTokenRepository) RevokeByUserID(userID ulid.ULID) error {
 	}
 	return nil
 }
+
+// DeleteExpired deletes up to limit password reset tokens that expired or were revoked before
+// cutoff and returns how many it deleted.
+func (r *PasswordResetTokenRepository) DeleteExpired(cutoff time.Time, limit int64) (int64, error) {
+	expired := PasswordResetTokens.SELECT(PasswordResetTokens.ID).
+		WHERE(OR(
+			PasswordResetTokens.ExpiresAt.LT(TimestampzT(cutoff)),
+			PasswordResetTokens.RevokedAt.LT(TimestampzT(cutoff)),
+		)).
+		LIMIT(limit)
+
+	result, err := PasswordResetTokens.DELETE().WHERE(PasswordResetTokens.ID.IN(expired)).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete expired password reset tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete expired password reset tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted, nil
+}
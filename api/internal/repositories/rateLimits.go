@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+type RateLimitRepository struct {
+	db DB
+}
+
+func NewRateLimitRepository(db DB) RateLimitRepository {
+	return RateLimitRepository{db: db}
+}
+
+// Increment counts a hit against key in a fixed window, starting a new window
+// if the previous one has expired. It returns the counter after the hit.
+func (r *RateLimitRepository) Increment(key string, window time.Duration) (*model.RateLimits, error) {
+	now := time.Now()
+	expired := RateLimits.ResetAt.LT_EQ(TimestampzT(now))
+
+	query := RateLimits.INSERT(RateLimits.Key, RateLimits.Count, RateLimits.ResetAt).
+		VALUES(String(key), Int(1), TimestampzT(now.Add(window))).
+		ON_CONFLICT(RateLimits.Key).
+		DO_UPDATE(SET(
+			RateLimits.Count.SET(IntExp(CASE().WHEN(expired).THEN(Int(1)).ELSE(RateLimits.Count.ADD(Int(1))))),
+			RateLimits.ResetAt.SET(TimestampzExp(CASE().WHEN(expired).THEN(RateLimits.EXCLUDED.ResetAt).ELSE(RateLimits.ResetAt))),
+		)).
+		RETURNING(RateLimits.AllColumns)
+
+	var limits []model.RateLimits
+	err := query.Query(r.db, &limits)
+	if err != nil {
+		log.Printf("[ERROR] Increment rate limit failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(limits) == 0 {
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return &limits[0], nil
+}
+
+// DeleteExpired deletes up to limit counters whose window ended before cutoff
+// and returns how many it deleted.
+func (r *RateLimitRepository) DeleteExpired(cutoff time.Time, limit int64) (int64, error) {
+	expired := RateLimits.SELECT(RateLimits.Key).
+		WHERE(RateLimits.ResetAt.LT(TimestampzT(cutoff))).
+		LIMIT(limit)
+
+	result, err := RateLimits.DELETE().WHERE(RateLimits.Key.IN(expired)).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete expired rate limits failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete expired rate limits failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted, nil
+}
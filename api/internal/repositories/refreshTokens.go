@@ -4,7 +4,6 @@ import (
 	"auth/internal/apperror"
 	"auth/internal/jet/postgres/public/model"
 	. "auth/internal/jet/postgres/public/table"
-	"database/sql"
 	"log"
 	"time"
 
@@ -13,10 +12,10 @@ import (
 )
 
 type RefreshTokenRepository struct {
-	db *sql.DB
+	db DB
 }
 
-func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+func NewRefreshTokenRepository(db DB) RefreshTokenRepository {
 	return RefreshTokenRepository{db: db}
 }
 
@@ -39,6 +38,22 @@ func (r *RefreshTokenRepository) GetByID(id ulid.ULID) (*model.RefreshTokens, er
 	return &tokens[0], nil
 }
 
+// ListByUserID returns all of the user's refresh tokens, newest first.
+func (r *RefreshTokenRepository) ListByUserID(userID ulid.ULID) ([]model.RefreshTokens, error) {
+	query := RefreshTokens.SELECT(RefreshTokens.AllColumns).
+		WHERE(RefreshTokens.UserID.EQ(Bytea(userID.Bytes()))).
+		ORDER_BY(RefreshTokens.IssuedAt.DESC())
+
+	var tokens []model.RefreshTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] List refresh tokens by userID failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return tokens, nil
+}
+
 func (r *RefreshTokenRepository) Revoke(id ulid.ULID) error {
 	_, err := RefreshTokens.UPDATE().
 		SET(RefreshTokens.RevokedAt.SET(TimestampzT(time.Now()))).
@@ -71,3 +86,28 @@ func (r *RefreshTokenRepository) Create(token model.RefreshTokens) error {
 	}
 	return nil
 }
+
+// DeleteExpired deletes up to limit refresh tokens that expired or were revoked before
+// cutoff and returns how many it deleted.
+func (r *RefreshTokenRepository) DeleteExpired(cutoff time.Time, limit int64) (int64, error) {
+	expired := RefreshTokens.SELECT(RefreshTokens.ID).
+		WHERE(OR(
+			RefreshTokens.ExpiresAt.LT(TimestampzT(cutoff)),
+			RefreshTokens.RevokedAt.LT(TimestampzT(cutoff)),
+		)).
+		LIMIT(limit)
+
+	result, err := RefreshTokens.DELETE().WHERE(RefreshTokens.ID.IN(expired)).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete expired refresh tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete expired refresh tokens failed: %v", err)
+		return 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+// retryQueue describes a table of work that is attempted until it succeeds,
+// such as the outbox or webhook deliveries. Each row waits in a pending status
+// until its next attempt is due.
+type retryQueue struct {
+	table         Table
+	columns       ColumnList
+	pending       string
+	id            ColumnBytea
+	status        ColumnString
+	attempts      ColumnInteger
+	nextAttemptAt ColumnTimestampz
+	// Set to the time of each attempt if the table records it
+	lastAttemptAt ColumnTimestampz
+}
+
+// claimDue picks up to limit pending rows whose next attempt is due and counts
+// the attempt, scanning them into dest. Their next attempt is pushed out by
+// lease so that another worker won't pick them up while they are being
+// handled, and if this worker dies they are retried once the lease runs out.
+func claimDue(db DB, queue retryQueue, limit int64, lease time.Duration, dest any) error {
+	now := time.Now()
+
+	due := queue.table.SELECT(queue.id).
+		WHERE(AND(
+			queue.status.EQ(String(queue.pending)),
+			queue.nextAttemptAt.LT_EQ(TimestampzT(now)),
+		)).
+		ORDER_BY(queue.nextAttemptAt.ASC()).
+		LIMIT(limit).
+		FOR(UPDATE().SKIP_LOCKED())
+
+	assignments := []any{
+		queue.attempts.SET(queue.attempts.ADD(Int(1))),
+		queue.nextAttemptAt.SET(TimestampzT(now.Add(lease))),
+	}
+	if queue.lastAttemptAt != nil {
+		assignments = append(assignments, queue.lastAttemptAt.SET(TimestampzT(now)))
+	}
+
+	return queue.table.UPDATE().
+		SET(assignments[0], assignments[1:]...).
+		WHERE(queue.id.IN(due)).
+		RETURNING(queue.columns).
+		Query(db, dest)
+}
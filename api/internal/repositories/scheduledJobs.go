@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+const (
+	ScheduledJobSucceeded = "succeeded"
+	ScheduledJobFailed    = "failed"
+)
+
+// ScheduledJobRepository records the last run of each periodic job, shared by
+// every replica so that a new leader picks up where the last one left off.
+type ScheduledJobRepository struct {
+	db DB
+}
+
+func NewScheduledJobRepository(db DB) ScheduledJobRepository {
+	return ScheduledJobRepository{db: db}
+}
+
+func (r *ScheduledJobRepository) List() ([]model.ScheduledJobs, error) {
+	query := ScheduledJobs.SELECT(ScheduledJobs.AllColumns).
+		ORDER_BY(ScheduledJobs.Name.ASC())
+
+	var jobs []model.ScheduledJobs
+	err := query.Query(r.db, &jobs)
+	if err != nil {
+		log.Printf("[ERROR] List scheduled jobs query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return jobs, nil
+}
+
+// RecordStart notes that a run of the job has begun, creating its record the
+// first time it runs.
+func (r *ScheduledJobRepository) RecordStart(name string, startedAt time.Time) error {
+	_, err := ScheduledJobs.INSERT(ScheduledJobs.Name, ScheduledJobs.LastStartedAt).
+		VALUES(name, startedAt).
+		ON_CONFLICT(ScheduledJobs.Name).
+		DO_UPDATE(SET(ScheduledJobs.LastStartedAt.SET(ScheduledJobs.EXCLUDED.LastStartedAt))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Record scheduled job start failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// RecordFinish stores the outcome of the job's latest run. lastError is nil
+// when it succeeded.
+func (r *ScheduledJobRepository) RecordFinish(name string, finishedAt time.Time, processed int64, lastError *string) error {
+	status := ScheduledJobSucceeded
+	errorExp := StringExp(NULL)
+	if lastError != nil {
+		status = ScheduledJobFailed
+		errorExp = String(*lastError)
+	}
+
+	_, err := ScheduledJobs.UPDATE(ScheduledJobs.LastFinishedAt, ScheduledJobs.LastStatus, ScheduledJobs.LastProcessed, ScheduledJobs.LastError).
+		SET(
+			ScheduledJobs.LastFinishedAt.SET(TimestampzT(finishedAt)),
+			ScheduledJobs.LastStatus.SET(String(status)),
+			ScheduledJobs.LastProcessed.SET(Int(processed)),
+			ScheduledJobs.LastError.SET(errorExp),
+		).
+		WHERE(ScheduledJobs.Name.EQ(String(name))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Record scheduled job finish failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
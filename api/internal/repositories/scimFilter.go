@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	. "auth/internal/jet/postgres/public/table"
+	"auth/internal/scim/filter"
+	"auth/internal/ulidutil"
+	"strings"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+)
+
+// scimAttribute builds the SQL condition for one filter comparison.
+type scimAttribute func(operator string, value any) (BoolExpression, error)
+
+var scimUserAttributes = map[string]scimAttribute{
+	"id":                scimIDAttribute("user", Users.ID),
+	"username":          scimStringAttribute(Users.Username, false),
+	"externalid":        scimStringAttribute(Users.ExternalID, true),
+	"emails":            scimStringAttribute(Users.Email, false),
+	"emails.value":      scimStringAttribute(Users.Email, false),
+	"emails.type":       scimConstantAttribute("work"),
+	"emails.primary":    scimConstantAttribute(true),
+	"active":            scimBoolAttribute(Users.Active),
+	"meta.created":      scimTimeAttribute(Users.CreatedAt),
+	"meta.lastmodified": scimTimeAttribute(Users.UpdatedAt),
+}
+
+var scimGroupAttributes = map[string]scimAttribute{
+	"id":                scimIDAttribute("group", Groups.ID),
+	"displayname":       scimStringAttribute(Groups.DisplayName, false),
+	"externalid":        scimStringAttribute(Groups.ExternalID, true),
+	"members":           scimGroupMemberAttribute,
+	"members.value":     scimGroupMemberAttribute,
+	"meta.created":      scimTimeAttribute(Groups.CreatedAt),
+	"meta.lastmodified": scimTimeAttribute(Groups.UpdatedAt),
+}
+
+// compileScimFilter translates a parsed filter into a WHERE condition. A nil
+// filter matches everything.
+func compileScimFilter(expr filter.Expression, attributes map[string]scimAttribute) (BoolExpression, error) {
+	switch e := expr.(type) {
+	case nil:
+		return Bool(true), nil
+	case filter.Comparison:
+		attribute, ok := attributes[e.Path]
+		if !ok {
+			return nil, &filter.Error{Message: "Filtering on " + e.Path + " is not supported"}
+		}
+		return attribute(e.Operator, e.Value)
+	case filter.Logical:
+		left, err := compileScimFilter(e.Left, attributes)
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileScimFilter(e.Right, attributes)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == "and" {
+			return AND(left, right), nil
+		}
+		return OR(left, right), nil
+	case filter.Not:
+		inner, err := compileScimFilter(e.Expression, attributes)
+		if err != nil {
+			return nil, err
+		}
+		return NOT(inner), nil
+	}
+	return nil, &filter.Error{Message: "Unsupported filter"}
+}
+
+func unsupportedOperator(operator string) error {
+	return &filter.Error{Message: "Operator " + operator + " is not supported for this attribute"}
+}
+
+// scimStringAttribute compares strings, ignoring case unless caseExact is set
+// as the SCIM core schema requires for most attributes.
+func scimStringAttribute(column ColumnString, caseExact bool) scimAttribute {
+	return func(operator string, value any) (BoolExpression, error) {
+		if operator == "pr" {
+			return AND(column.IS_NOT_NULL(), column.NOT_EQ(String(""))), nil
+		}
+
+		s, ok := value.(string)
+		if !ok {
+			return nil, &filter.Error{Message: "Expected a string value"}
+		}
+
+		var lhs StringExpression = column
+		if !caseExact {
+			lhs = LOWER(column)
+			s = strings.ToLower(s)
+		}
+
+		switch operator {
+		case "eq":
+			return lhs.EQ(String(s)), nil
+		case "ne":
+			return lhs.NOT_EQ(String(s)), nil
+		case "co":
+			return lhs.LIKE(String("%" + escapeLike(s) + "%")), nil
+		case "sw":
+			return lhs.LIKE(String(escapeLike(s) + "%")), nil
+		case "ew":
+			return lhs.LIKE(String("%" + escapeLike(s))), nil
+		case "gt":
+			return lhs.GT(String(s)), nil
+		case "ge":
+			return lhs.GT_EQ(String(s)), nil
+		case "lt":
+			return lhs.LT(String(s)), nil
+		case "le":
+			return lhs.LT_EQ(String(s)), nil
+		}
+		return nil, unsupportedOperator(operator)
+	}
+}
+
+func scimBoolAttribute(column ColumnBool) scimAttribute {
+	return func(operator string, value any) (BoolExpression, error) {
+		if operator == "pr" {
+			return Bool(true), nil
+		}
+
+		b, ok := value.(bool)
+		if !ok {
+			return nil, &filter.Error{Message: "Expected a boolean value"}
+		}
+
+		switch operator {
+		case "eq":
+			return column.EQ(Bool(b)), nil
+		case "ne":
+			return column.NOT_EQ(Bool(b)), nil
+		}
+		return nil, unsupportedOperator(operator)
+	}
+}
+
+func scimTimeAttribute(column ColumnTimestampz) scimAttribute {
+	return func(operator string, value any) (BoolExpression, error) {
+		if operator == "pr" {
+			return Bool(true), nil
+		}
+
+		s, _ := value.(string)
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, &filter.Error{Message: "Expected an RFC 3339 timestamp"}
+		}
+
+		switch operator {
+		case "eq":
+			return column.EQ(TimestampzT(t)), nil
+		case "ne":
+			return column.NOT_EQ(TimestampzT(t)), nil
+		case "gt":
+			return column.GT(TimestampzT(t)), nil
+		case "ge":
+			return column.GT_EQ(TimestampzT(t)), nil
+		case "lt":
+			return column.LT(TimestampzT(t)), nil
+		case "le":
+			return column.LT_EQ(TimestampzT(t)), nil
+		}
+		return nil, unsupportedOperator(operator)
+	}
+}
+
+// scimConstantAttribute is for attributes that have the same value for every
+// resource, such as the type of the only email address a user has.
+func scimConstantAttribute(constant any) scimAttribute {
+	return func(operator string, value any) (BoolExpression, error) {
+		switch operator {
+		case "pr":
+			return Bool(true), nil
+		case "eq":
+			return Bool(value == constant), nil
+		case "ne":
+			return Bool(value != constant), nil
+		}
+		return nil, unsupportedOperator(operator)
+	}
+}
+
+// scimIDAttribute matches prefixed IDs. An ID that doesn't parse can't match
+// anything, so it isn't treated as an error.
+func scimIDAttribute(prefix string, column ColumnBytea) scimAttribute {
+	return func(operator string, value any) (BoolExpression, error) {
+		if operator == "pr" {
+			return Bool(true), nil
+		}
+
+		s, _ := value.(string)
+		id, err := ulidutil.FromPrefixed(prefix, s)
+
+		switch operator {
+		case "eq":
+			if err != nil {
+				return Bool(false), nil
+			}
+			return column.EQ(Bytea(id.Bytes())), nil
+		case "ne":
+			if err != nil {
+				return Bool(true), nil
+			}
+			return column.NOT_EQ(Bytea(id.Bytes())), nil
+		}
+		return nil, unsupportedOperator(operator)
+	}
+}
+
+func scimGroupMemberAttribute(operator string, value any) (BoolExpression, error) {
+	if operator == "pr" {
+		return Groups.ID.IN(GroupMembers.SELECT(GroupMembers.GroupID)), nil
+	}
+	if operator != "eq" {
+		return nil, unsupportedOperator(operator)
+	}
+
+	s, _ := value.(string)
+	userID, err := ulidutil.FromPrefixed("user", s)
+	if err != nil {
+		return Bool(false), nil
+	}
+	return Groups.ID.IN(
+		GroupMembers.SELECT(GroupMembers.GroupID).
+			WHERE(GroupMembers.UserID.EQ(Bytea(userID.Bytes()))),
+	), nil
+}
+
+// escapeLike escapes the LIKE wildcards so user input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type ScimTenantRepository struct {
+	db DB
+}
+
+func NewScimTenantRepository(db DB) ScimTenantRepository {
+	return ScimTenantRepository{db: db}
+}
+
+func (r *ScimTenantRepository) Create(tenant model.ScimTenants) error {
+	_, err := ScimTenants.INSERT().MODEL(tenant).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create SCIM tenant failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *ScimTenantRepository) GetByID(id ulid.ULID) (*model.ScimTenants, error) {
+	query := ScimTenants.SELECT(ScimTenants.AllColumns).
+		WHERE(ScimTenants.ID.EQ(Bytea(id.Bytes()))).
+		LIMIT(1)
+
+	var tenants []model.ScimTenants
+	err := query.Query(r.db, &tenants)
+	if err != nil {
+		log.Printf("[ERROR] GetByID query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(tenants) == 0 {
+		return nil, apperror.NewNotFound("Tenant not found")
+	}
+
+	return &tenants[0], nil
+}
+
+func (r *ScimTenantRepository) NameExists(name string) (bool, error) {
+	query := ScimTenants.SELECT(ScimTenants.ID).
+		WHERE(ScimTenants.Name.EQ(String(name))).
+		LIMIT(1)
+
+	var tenants []model.ScimTenants
+	err := query.Query(r.db, &tenants)
+	if err != nil {
+		log.Printf("[ERROR] NameExists query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(tenants) > 0, nil
+}
+
+func (r *ScimTenantRepository) List() ([]model.ScimTenants, error) {
+	query := ScimTenants.SELECT(ScimTenants.AllColumns).
+		ORDER_BY(ScimTenants.Name.ASC())
+
+	var tenants []model.ScimTenants
+	err := query.Query(r.db, &tenants)
+	if err != nil {
+		log.Printf("[ERROR] List SCIM tenants query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return tenants, nil
+}
+
+func (r *ScimTenantRepository) Delete(id ulid.ULID) error {
+	_, err := ScimTenants.DELETE().WHERE(ScimTenants.ID.EQ(Bytea(id.Bytes()))).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete SCIM tenant failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type ScimTokenRepository struct {
+	db DB
+}
+
+func NewScimTokenRepository(db DB) ScimTokenRepository {
+	return ScimTokenRepository{db: db}
+}
+
+func (r *ScimTokenRepository) Create(token model.ScimTokens) error {
+	_, err := ScimTokens.INSERT().MODEL(token).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create SCIM token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// GetActiveByHash returns the unrevoked token with the given hash, or nil.
+func (r *ScimTokenRepository) GetActiveByHash(hash []byte) (*model.ScimTokens, error) {
+	query := ScimTokens.SELECT(ScimTokens.AllColumns).
+		WHERE(AND(ScimTokens.TokenHash.EQ(Bytea(hash)), ScimTokens.RevokedAt.IS_NULL())).
+		LIMIT(1)
+
+	var tokens []model.ScimTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] GetActiveByHash query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	return &tokens[0], nil
+}
+
+func (r *ScimTokenRepository) ListByTenant(tenantID ulid.ULID) ([]model.ScimTokens, error) {
+	query := ScimTokens.SELECT(ScimTokens.AllColumns).
+		WHERE(ScimTokens.TenantID.EQ(Bytea(tenantID.Bytes()))).
+		ORDER_BY(ScimTokens.ID.DESC())
+
+	var tokens []model.ScimTokens
+	err := query.Query(r.db, &tokens)
+	if err != nil {
+		log.Printf("[ERROR] List SCIM tokens query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return tokens, nil
+}
+
+func (r *ScimTokenRepository) Touch(id ulid.ULID) error {
+	_, err := ScimTokens.UPDATE().
+		SET(ScimTokens.LastUsedAt.SET(TimestampzT(time.Now()))).
+		WHERE(ScimTokens.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Touch SCIM token failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// Revoke revokes a token belonging to the tenant, reporting whether one was
+// found.
+func (r *ScimTokenRepository) Revoke(tenantID ulid.ULID, id ulid.ULID) (bool, error) {
+	result, err := ScimTokens.UPDATE().
+		SET(ScimTokens.RevokedAt.SET(TimestampzT(time.Now()))).
+		WHERE(AND(
+			ScimTokens.ID.EQ(Bytea(id.Bytes())),
+			ScimTokens.TenantID.EQ(Bytea(tenantID.Bytes())),
+			ScimTokens.RevokedAt.IS_NULL(),
+		)).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Revoke SCIM token failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Revoke SCIM token failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return revoked > 0, nil
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"database/sql"
+	"log"
+
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+// DB is satisfied by both *sql.DB and *sql.Tx, so any repository can be
+// built on a transaction to take part in a unit of work.
+type DB interface {
+	qrm.Queryable
+	qrm.Executable
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise. Repositories that should share the transaction are built
+// on the tx passed to fn.
+func WithTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("[ERROR] Begin transaction failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[ERROR] Commit transaction failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
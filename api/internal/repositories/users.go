@@ -4,7 +4,7 @@ import (
 	"auth/internal/apperror"
 	"auth/internal/jet/postgres/public/model"
 	. "auth/internal/jet/postgres/public/table"
-	"database/sql"
+	"auth/internal/scim/filter"
 	"log"
 	"time"
 
@@ -13,10 +13,10 @@ import (
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db DB
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
+func NewUserRepository(db DB) UserRepository {
 	return UserRepository{db: db}
 }
 
@@ -39,6 +39,28 @@ func (r *UserRepository) GetByID(id ulid.ULID) (*model.Users, error) {
 	return &users[0], nil
 }
 
+// GetByIDForUpdate is GetByID that also locks the user's row until the
+// transaction r was built on ends, serializing changes made on their behalf.
+func (r *UserRepository) GetByIDForUpdate(id ulid.ULID) (*model.Users, error) {
+	query := Users.SELECT(Users.AllColumns).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		LIMIT(1).
+		FOR(UPDATE())
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] GetByIDForUpdate query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(users) == 0 {
+		return nil, apperror.NewNotFound("User not found")
+	}
+
+	return &users[0], nil
+}
+
 func (r *UserRepository) GetByEmail(email string) (*model.Users, error) {
 	query := Users.SELECT(Users.AllColumns).
 		WHERE(Users.Email.EQ(String(email))).
@@ -69,9 +91,128 @@ func (r *UserRepository) Create(user model.Users) error {
 	return nil
 }
 
+// Import inserts a user as given, keeping its ID and timestamps. It reports
+// false if a user with the same ID or a unique field already exists.
+func (r *UserRepository) Import(user model.Users) (bool, error) {
+	result, err := Users.INSERT().MODEL(user).ON_CONFLICT().DO_NOTHING().Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Import user failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Import user failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return inserted > 0, nil
+}
+
+func (r *UserRepository) Exists(id ulid.ULID) (bool, error) {
+	query := Users.SELECT(Users.ID).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		LIMIT(1)
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] Exists query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(users) > 0, nil
+}
+
+func (r *UserRepository) UsernameExists(username string) (bool, error) {
+	query := Users.SELECT(Users.ID).
+		WHERE(Users.Username.EQ(String(username))).
+		LIMIT(1)
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] UsernameExists query failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return len(users) > 0, nil
+}
+
+// ListAfter returns up to limit users ordered by ID, starting after the given
+// ID or from the beginning if it is nil.
+func (r *UserRepository) ListAfter(after *ulid.ULID, limit int64) ([]model.Users, error) {
+	condition := Bool(true)
+	if after != nil {
+		condition = Users.ID.GT(Bytea(after.Bytes()))
+	}
+
+	query := Users.SELECT(Users.AllColumns).
+		WHERE(condition).
+		ORDER_BY(Users.ID.ASC()).
+		LIMIT(limit)
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] List users query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return users, nil
+}
+
+// ListByScimTenant returns a page of the tenant's provisioned users matching
+// the filter along with the total number of matches.
+func (r *UserRepository) ListByScimTenant(tenantID ulid.ULID, expr filter.Expression, offset int64, limit int64) ([]model.Users, int64, error) {
+	condition, err := compileScimFilter(expr, scimUserAttributes)
+	if err != nil {
+		return nil, 0, err
+	}
+	condition = AND(Users.ScimTenantID.EQ(Bytea(tenantID.Bytes())), condition)
+
+	var count struct {
+		Count int64 `alias:"count"`
+	}
+	err = SELECT(COUNT(STAR).AS("count")).FROM(Users).WHERE(condition).Query(r.db, &count)
+	if err != nil {
+		log.Printf("[ERROR] Count users query failed: %v", err)
+		return nil, 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	query := Users.SELECT(Users.AllColumns).
+		WHERE(condition).
+		ORDER_BY(Users.ID.ASC()).
+		OFFSET(offset).
+		LIMIT(limit)
+
+	var users []model.Users
+	err = query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] List users query failed: %v", err)
+		return nil, 0, apperror.NewInternalServerError("Database query error")
+	}
+
+	return users, count.Count, nil
+}
+
+// UpdateProvisioned saves the attributes an identity provider manages.
+func (r *UserRepository) UpdateProvisioned(user model.Users) error {
+	user.UpdatedAt = time.Now()
+	_, err := Users.UPDATE(Users.Email, Users.Username, Users.EmailVerified, Users.Active, Users.ExternalID, Users.UpdatedAt).
+		MODEL(user).
+		WHERE(Users.ID.EQ(Bytea(user.ID))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Update provisioned user failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
 func (r *UserRepository) Update(user model.Users) error {
 	user.UpdatedAt = time.Now()
-	_, err := Users.UPDATE(Users.Email, Users.Username, Users.EmailVerified, Users.UpdatedAt).MODEL(user).WHERE(Users.ID.EQ(Bytea(user.ID))).Exec(r.db)
+	_, err := Users.UPDATE(Users.Email, Users.Username, Users.EmailVerified, Users.Locale, Users.UpdatedAt).MODEL(user).WHERE(Users.ID.EQ(Bytea(user.ID))).Exec(r.db)
 	if err != nil {
 		log.Printf("[ERROR] Update user failed: %v", err)
 		return apperror.NewInternalServerError("Database query error")
@@ -88,6 +229,24 @@ func (r *UserRepository) SetPassword(id ulid.ULID, passwordHash string) error {
 	return nil
 }
 
+// SetVerifiedEmail changes the user's email to an address they have proven
+// they own.
+func (r *UserRepository) SetVerifiedEmail(id ulid.ULID, email string) error {
+	_, err := Users.UPDATE(Users.Email, Users.EmailVerified, Users.UpdatedAt).
+		SET(
+			Users.Email.SET(String(email)),
+			Users.EmailVerified.SET(Bool(true)),
+			Users.UpdatedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Set verified email failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
 func (r *UserRepository) SetEmailVerified(id ulid.ULID) error {
 	_, err := Users.UPDATE(Users.EmailVerified).
 		SET(Users.EmailVerified.SET(Bool(true)), Users.UpdatedAt.SET(TimestampzT(time.Now()))).
@@ -100,6 +259,75 @@ func (r *UserRepository) SetEmailVerified(id ulid.ULID) error {
 	return nil
 }
 
+// RecordFailedLogin increments the consecutive failed login counter and returns
+// the updated user.
+func (r *UserRepository) RecordFailedLogin(id ulid.ULID) (*model.Users, error) {
+	query := Users.UPDATE(Users.FailedLoginAttempts, Users.LastFailedLoginAt).
+		SET(
+			Users.FailedLoginAttempts.SET(Users.FailedLoginAttempts.ADD(Int(1))),
+			Users.LastFailedLoginAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		RETURNING(Users.AllColumns)
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] Record failed login failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(users) == 0 {
+		return nil, apperror.NewNotFound("User not found")
+	}
+
+	return &users[0], nil
+}
+
+func (r *UserRepository) Lock(id ulid.ULID, until time.Time) error {
+	_, err := Users.UPDATE(Users.LockedUntil).
+		SET(Users.LockedUntil.SET(TimestampzT(until))).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Lock user failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// ClearLockout resets the failed login counter and removes any lock.
+func (r *UserRepository) ClearLockout(id ulid.ULID) error {
+	_, err := Users.UPDATE(Users.FailedLoginAttempts, Users.LastFailedLoginAt, Users.LockedUntil).
+		SET(
+			Users.FailedLoginAttempts.SET(Int(0)),
+			Users.LastFailedLoginAt.SET(TimestampzExp(NULL)),
+			Users.LockedUntil.SET(TimestampzExp(NULL)),
+		).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Clear lockout failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+func (r *UserRepository) ListLocked() ([]model.Users, error) {
+	query := Users.SELECT(Users.AllColumns).
+		WHERE(Users.LockedUntil.GT(TimestampzT(time.Now()))).
+		ORDER_BY(Users.LockedUntil.DESC())
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] List locked users query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return users, nil
+}
+
 func (r *UserRepository) Delete(id ulid.ULID) error {
 	_, err := Users.DELETE().WHERE(Users.ID.EQ(Bytea(id.Bytes()))).Exec(r.db)
 	if err != nil {
@@ -109,6 +337,77 @@ func (r *UserRepository) Delete(id ulid.ULID) error {
 	return nil
 }
 
+// ScheduleDeletion marks the user to be purged once at has passed.
+func (r *UserRepository) ScheduleDeletion(id ulid.ULID, at time.Time) error {
+	_, err := Users.UPDATE(Users.DeleteAfter).
+		SET(Users.DeleteAfter.SET(TimestampzT(at))).
+		WHERE(Users.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Schedule user deletion failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// CancelDeletion reports whether the user was still scheduled for deletion.
+func (r *UserRepository) CancelDeletion(id ulid.ULID) (bool, error) {
+	result, err := Users.UPDATE(Users.DeleteAfter).
+		SET(Users.DeleteAfter.SET(TimestampzExp(NULL))).
+		WHERE(AND(Users.ID.EQ(Bytea(id.Bytes())), Users.DeleteAfter.IS_NOT_NULL())).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Cancel user deletion failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Cancel user deletion failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return updated > 0, nil
+}
+
+// ListDueForDeletion returns up to limit users whose deletion grace period
+// has ended, oldest first.
+func (r *UserRepository) ListDueForDeletion(limit int64) ([]model.Users, error) {
+	query := Users.SELECT(Users.AllColumns).
+		WHERE(Users.DeleteAfter.LT_EQ(TimestampzT(time.Now()))).
+		ORDER_BY(Users.DeleteAfter.ASC()).
+		LIMIT(limit)
+
+	var users []model.Users
+	err := query.Query(r.db, &users)
+	if err != nil {
+		log.Printf("[ERROR] List users due for deletion query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return users, nil
+}
+
+// DeleteIfDue deletes the user if their deletion grace period has ended,
+// reporting false if it was cancelled in the meantime.
+func (r *UserRepository) DeleteIfDue(id ulid.ULID) (bool, error) {
+	result, err := Users.DELETE().
+		WHERE(AND(Users.ID.EQ(Bytea(id.Bytes())), Users.DeleteAfter.LT_EQ(TimestampzT(time.Now())))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete due user failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete due user failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted > 0, nil
+}
+
 func (r *UserRepository) WillConflict(user model.Users) (bool, error) {
 	query := Users.SELECT(Users.ID).
 		WHERE(
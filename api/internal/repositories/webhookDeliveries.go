@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookDeliveryRepository struct {
+	db DB
+}
+
+func NewWebhookDeliveryRepository(db DB) WebhookDeliveryRepository {
+	return WebhookDeliveryRepository{db: db}
+}
+
+func (r *WebhookDeliveryRepository) Create(deliveries ...model.WebhookDeliveries) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := WebhookDeliveries.INSERT().MODELS(deliveries).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create webhook deliveries failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// GetByID returns the delivery with the given id if it belongs to the
+// subscription, or nil.
+func (r *WebhookDeliveryRepository) GetByID(subscriptionID ulid.ULID, id ulid.ULID) (*model.WebhookDeliveries, error) {
+	query := WebhookDeliveries.SELECT(WebhookDeliveries.AllColumns).
+		WHERE(AND(
+			WebhookDeliveries.ID.EQ(Bytea(id.Bytes())),
+			WebhookDeliveries.SubscriptionID.EQ(Bytea(subscriptionID.Bytes())),
+		)).
+		LIMIT(1)
+
+	var deliveries []model.WebhookDeliveries
+	err := query.Query(r.db, &deliveries)
+	if err != nil {
+		log.Printf("[ERROR] Get webhook delivery query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	return &deliveries[0], nil
+}
+
+// ListBySubscription returns a page of a subscription's deliveries, newest
+// first, starting after the before cursor when one is given.
+func (r *WebhookDeliveryRepository) ListBySubscription(subscriptionID ulid.ULID, status string, before *ulid.ULID, limit int64) ([]model.WebhookDeliveries, error) {
+	conditions := []BoolExpression{
+		WebhookDeliveries.SubscriptionID.EQ(Bytea(subscriptionID.Bytes())),
+	}
+	if status != "" {
+		conditions = append(conditions, WebhookDeliveries.Status.EQ(String(status)))
+	}
+	if before != nil {
+		conditions = append(conditions, WebhookDeliveries.ID.LT(Bytea(before.Bytes())))
+	}
+
+	query := WebhookDeliveries.SELECT(WebhookDeliveries.AllColumns).
+		WHERE(AND(conditions...)).
+		ORDER_BY(WebhookDeliveries.ID.DESC()).
+		LIMIT(limit)
+
+	var deliveries []model.WebhookDeliveries
+	err := query.Query(r.db, &deliveries)
+	if err != nil {
+		log.Printf("[ERROR] List webhook deliveries query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deliveries, nil
+}
+
+var webhookDeliveryQueue = retryQueue{
+	table:         WebhookDeliveries,
+	columns:       WebhookDeliveries.AllColumns,
+	pending:       WebhookDeliveryPending,
+	id:            WebhookDeliveries.ID,
+	status:        WebhookDeliveries.Status,
+	attempts:      WebhookDeliveries.Attempts,
+	nextAttemptAt: WebhookDeliveries.NextAttemptAt,
+	lastAttemptAt: WebhookDeliveries.LastAttemptAt,
+}
+
+// ClaimDue picks up to limit pending deliveries whose next attempt is due and
+// counts the attempt, hiding them from other workers for lease.
+func (r *WebhookDeliveryRepository) ClaimDue(limit int64, lease time.Duration) ([]model.WebhookDeliveries, error) {
+	var deliveries []model.WebhookDeliveries
+	err := claimDue(r.db, webhookDeliveryQueue, limit, lease, &deliveries)
+	if err != nil {
+		log.Printf("[ERROR] Claim webhook deliveries query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt stores the outcome of an attempt. status is pending when the
+// delivery will be retried at nextAttemptAt.
+func (r *WebhookDeliveryRepository) RecordAttempt(id ulid.ULID, status string, responseStatus *int32, lastError *string, nextAttemptAt time.Time) error {
+	now := time.Now()
+
+	deliveredAt := TimestampzExp(NULL)
+	if status == WebhookDeliveryDelivered {
+		deliveredAt = TimestampzT(now)
+	}
+	responseStatusExp := IntExp(NULL)
+	if responseStatus != nil {
+		responseStatusExp = Int32(*responseStatus)
+	}
+	lastErrorExp := StringExp(NULL)
+	if lastError != nil {
+		lastErrorExp = String(*lastError)
+	}
+
+	_, err := WebhookDeliveries.UPDATE().
+		SET(
+			WebhookDeliveries.Status.SET(String(status)),
+			WebhookDeliveries.ResponseStatus.SET(responseStatusExp),
+			WebhookDeliveries.LastError.SET(lastErrorExp),
+			WebhookDeliveries.DeliveredAt.SET(deliveredAt),
+			WebhookDeliveries.NextAttemptAt.SET(TimestampzT(nextAttemptAt)),
+		).
+		WHERE(WebhookDeliveries.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Record webhook delivery attempt failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
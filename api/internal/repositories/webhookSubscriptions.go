@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/jet/postgres/public/model"
+	. "auth/internal/jet/postgres/public/table"
+	"log"
+	"time"
+
+	. "github.com/go-jet/jet/v2/postgres"
+	"github.com/oklog/ulid/v2"
+)
+
+type WebhookSubscriptionRepository struct {
+	db DB
+}
+
+func NewWebhookSubscriptionRepository(db DB) WebhookSubscriptionRepository {
+	return WebhookSubscriptionRepository{db: db}
+}
+
+func (r *WebhookSubscriptionRepository) Create(subscription model.WebhookSubscriptions) error {
+	_, err := WebhookSubscriptions.INSERT().MODEL(subscription).Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Create webhook subscription failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// GetByID returns the subscription with the given id, or nil.
+func (r *WebhookSubscriptionRepository) GetByID(id ulid.ULID) (*model.WebhookSubscriptions, error) {
+	query := WebhookSubscriptions.SELECT(WebhookSubscriptions.AllColumns).
+		WHERE(WebhookSubscriptions.ID.EQ(Bytea(id.Bytes()))).
+		LIMIT(1)
+
+	var subscriptions []model.WebhookSubscriptions
+	err := query.Query(r.db, &subscriptions)
+	if err != nil {
+		log.Printf("[ERROR] Get webhook subscription query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	return &subscriptions[0], nil
+}
+
+func (r *WebhookSubscriptionRepository) List() ([]model.WebhookSubscriptions, error) {
+	query := WebhookSubscriptions.SELECT(WebhookSubscriptions.AllColumns).
+		ORDER_BY(WebhookSubscriptions.ID.ASC())
+
+	var subscriptions []model.WebhookSubscriptions
+	err := query.Query(r.db, &subscriptions)
+	if err != nil {
+		log.Printf("[ERROR] List webhook subscriptions query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return subscriptions, nil
+}
+
+// ListActiveForEvent returns the active subscriptions whose event types
+// include eventType.
+func (r *WebhookSubscriptionRepository) ListActiveForEvent(eventType string) ([]model.WebhookSubscriptions, error) {
+	query := WebhookSubscriptions.SELECT(WebhookSubscriptions.AllColumns).
+		WHERE(AND(
+			WebhookSubscriptions.Active.IS_TRUE(),
+			BoolExp(Raw("webhook_subscriptions.event_types @> jsonb_build_array(#eventType::text)", RawArgs{"#eventType": eventType})),
+		))
+
+	var subscriptions []model.WebhookSubscriptions
+	err := query.Query(r.db, &subscriptions)
+	if err != nil {
+		log.Printf("[ERROR] List webhook subscriptions for event query failed: %v", err)
+		return nil, apperror.NewInternalServerError("Database query error")
+	}
+
+	return subscriptions, nil
+}
+
+func (r *WebhookSubscriptionRepository) Update(subscription model.WebhookSubscriptions) error {
+	_, err := WebhookSubscriptions.UPDATE().
+		SET(
+			WebhookSubscriptions.URL.SET(String(subscription.URL)),
+			WebhookSubscriptions.EventTypes.SET(StringExp(Raw("#eventTypes::jsonb", RawArgs{"#eventTypes": subscription.EventTypes}))),
+			WebhookSubscriptions.Active.SET(Bool(subscription.Active)),
+			WebhookSubscriptions.UpdatedAt.SET(TimestampzT(time.Now())),
+		).
+		WHERE(WebhookSubscriptions.ID.EQ(Bytea(subscription.ID))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Update webhook subscription failed: %v", err)
+		return apperror.NewInternalServerError("Database query error")
+	}
+	return nil
+}
+
+// Delete removes a subscription along with its delivery log, reporting whether
+// one was found.
+func (r *WebhookSubscriptionRepository) Delete(id ulid.ULID) (bool, error) {
+	result, err := WebhookSubscriptions.DELETE().
+		WHERE(WebhookSubscriptions.ID.EQ(Bytea(id.Bytes()))).
+		Exec(r.db)
+	if err != nil {
+		log.Printf("[ERROR] Delete webhook subscription failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[ERROR] Delete webhook subscription failed: %v", err)
+		return false, apperror.NewInternalServerError("Database query error")
+	}
+
+	return deleted > 0, nil
+}
@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	initialBackoff = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+
+	// Only the start of an error is kept for the record
+	maxErrorLength = 1000
+)
+
+type Config struct {
+	PollInterval time.Duration
+	MaxAttempts  int
+	BatchSize    int
+	// How long a claimed item is hidden from other workers. Processing
+	// should finish well within it or the item may be processed twice.
+	Lease time.Duration
+}
+
+// Worker drains a queue kept in the database, such as the outbox or webhook
+// deliveries. It claims due items in batches and processes each batch
+// concurrently. Claiming counts an attempt, and process records the outcome,
+// using NextAttempt to schedule a retry after a failure. Several workers,
+// in one instance or many, can share a queue.
+type Worker[T any] struct {
+	config  Config
+	claim   func(limit int64, lease time.Duration) ([]T, error)
+	process func(ctx context.Context, item T)
+}
+
+// NewWorker takes claim to pick up to limit due items, hiding them from other
+// workers for lease, and process to handle one of them.
+func NewWorker[T any](config Config, claim func(limit int64, lease time.Duration) ([]T, error), process func(ctx context.Context, item T)) *Worker[T] {
+	return &Worker[T]{config: config, claim: claim, process: process}
+}
+
+// Run processes due items until ctx is cancelled.
+func (w *Worker[T]) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going without waiting while there is a backlog
+		for w.processDue(ctx) == w.config.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDue handles a batch of due items concurrently and returns how many
+// it claimed.
+func (w *Worker[T]) processDue(ctx context.Context) int {
+	items, err := w.claim(int64(w.config.BatchSize), w.config.Lease)
+	if err != nil {
+		return 0
+	}
+
+	done := make(chan struct{})
+	for _, item := range items {
+		go func() {
+			w.process(ctx, item)
+			done <- struct{}{}
+		}()
+	}
+	for range items {
+		<-done
+	}
+
+	return len(items)
+}
+
+// NextAttempt returns when an item that failed after the given number of
+// attempts should be retried, doubling the wait after each failure up to
+// maxBackoff. It returns false once the item has used up its attempts.
+func (w *Worker[T]) NextAttempt(attempts int32) (time.Time, bool) {
+	if int(attempts) >= w.config.MaxAttempts {
+		return time.Time{}, false
+	}
+
+	delay := initialBackoff
+	for i := int32(1); i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return time.Now().Add(min(delay, maxBackoff)), true
+}
+
+// ErrorMessage returns the start of err's message, short enough to store.
+// Messages can quote what a remote server sent back, so anything Postgres
+// won't accept as text, such as invalid UTF-8 or NUL bytes, is replaced.
+func ErrorMessage(err error) string {
+	message := strings.ToValidUTF8(err.Error(), "\uFFFD")
+	message = strings.ReplaceAll(message, "\x00", "\uFFFD")
+	if len(message) <= maxErrorLength {
+		return message
+	}
+
+	// Cut on a character boundary
+	end := maxErrorLength
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
@@ -0,0 +1,184 @@
+package scim
+
+import "net/http"
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkConfig             `json:"bulk"`
+	Filter                filterConfig           `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	Etag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	Meta                  discoveryMeta          `json:"meta"`
+}
+
+type bulkConfig struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type filterConfig struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type discoveryMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type ResourceType struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Endpoint    string        `json:"endpoint"`
+	Description string        `json:"description"`
+	Schema      string        `json:"schema"`
+	Meta        discoveryMeta `json:"meta"`
+}
+
+type Schema struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Attributes  []Attribute   `json:"attributes"`
+	Meta        discoveryMeta `json:"meta"`
+}
+
+type Attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	Description   string      `json:"description"`
+	Required      bool        `json:"required"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	Uniqueness    string      `json:"uniqueness"`
+	SubAttributes []Attribute `json:"subAttributes,omitempty"`
+}
+
+func (s *ScimService) ServiceProviderConfig() ServiceProviderConfig {
+	return ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          supported{Supported: true},
+		Bulk:           bulkConfig{Supported: false},
+		Filter:         filterConfig{Supported: true, MaxResults: maxCount},
+		ChangePassword: supported{Supported: true},
+		Sort:           supported{Supported: false},
+		Etag:           supported{Supported: false},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer Token",
+			Description: "A bearer token issued to the tenant by an administrator",
+			Primary:     true,
+		}},
+		Meta: discoveryMeta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     s.baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+func (s *ScimService) ResourceTypes() []ResourceType {
+	return []ResourceType{
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          "User",
+			Name:        "User",
+			Endpoint:    "/Users",
+			Description: "User account",
+			Schema:      SchemaUser,
+			Meta:        discoveryMeta{ResourceType: "ResourceType", Location: s.baseURL + "/ResourceTypes/User"},
+		},
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group of users",
+			Schema:      SchemaGroup,
+			Meta:        discoveryMeta{ResourceType: "ResourceType", Location: s.baseURL + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+func (s *ScimService) ResourceType(id string) (ResourceType, error) {
+	for _, resourceType := range s.ResourceTypes() {
+		if resourceType.ID == id {
+			return resourceType, nil
+		}
+	}
+	return ResourceType{}, newError(http.StatusNotFound, "", "Resource type not found")
+}
+
+// Schemas describes only the attributes this service stores. Others sent by
+// identity providers are accepted and ignored.
+func (s *ScimService) Schemas() []Schema {
+	return []Schema{
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaUser,
+			Name:        "User",
+			Description: "User account",
+			Attributes: []Attribute{
+				{Name: "userName", Type: "string", Description: "Unique identifier for the user", Required: true, Mutability: "readWrite", Returned: "default", Uniqueness: "server"},
+				{Name: "password", Type: "string", Description: "The user's password", Mutability: "writeOnly", Returned: "never", Uniqueness: "none"},
+				{Name: "active", Type: "boolean", Description: "Whether the user may sign in", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+				{
+					Name: "emails", Type: "complex", MultiValued: true, Description: "Email address of the user, only one is stored",
+					Required: true, Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+					SubAttributes: []Attribute{
+						{Name: "value", Type: "string", Description: "Email address", Required: true, Mutability: "readWrite", Returned: "default", Uniqueness: "server"},
+						{Name: "type", Type: "string", Description: "Always work", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+						{Name: "primary", Type: "boolean", Description: "Always true", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+					},
+				},
+			},
+			Meta: discoveryMeta{ResourceType: "Schema", Location: s.baseURL + "/Schemas/" + SchemaUser},
+		},
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaGroup,
+			Name:        "Group",
+			Description: "Group of users",
+			Attributes: []Attribute{
+				{Name: "displayName", Type: "string", Description: "Name of the group, unique within the tenant", Required: true, Mutability: "readWrite", Returned: "default", Uniqueness: "server"},
+				{
+					Name: "members", Type: "complex", MultiValued: true, Description: "Users in the group",
+					Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+					SubAttributes: []Attribute{
+						{Name: "value", Type: "string", Description: "ID of the user", CaseExact: true, Mutability: "immutable", Returned: "default", Uniqueness: "none"},
+						{Name: "display", Type: "string", Description: "User name of the user", Mutability: "readOnly", Returned: "default", Uniqueness: "none"},
+						{Name: "$ref", Type: "reference", Description: "URI of the user", CaseExact: true, Mutability: "readOnly", Returned: "default", Uniqueness: "none"},
+					},
+				},
+			},
+			Meta: discoveryMeta{ResourceType: "Schema", Location: s.baseURL + "/Schemas/" + SchemaGroup},
+		},
+	}
+}
+
+func (s *ScimService) Schema(id string) (Schema, error) {
+	for _, schema := range s.Schemas() {
+		if schema.ID == id {
+			return schema, nil
+		}
+	}
+	return Schema{}, newError(http.StatusNotFound, "", "Schema not found")
+}
@@ -0,0 +1,367 @@
+// Package filter parses SCIM filter expressions and attribute paths as
+// described in RFC 7644 section 3.4.2.2.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error describes a filter that is malformed or uses attributes or operators
+// that aren't supported.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(format string, args ...any) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+type Expression interface {
+	isExpression()
+}
+
+// Comparison tests a single attribute. Path is lower case with any schema URN
+// removed and sub-attributes joined by dots, such as "emails.value". Value is
+// a string, float64, bool or nil, and is unused for the "pr" operator.
+type Comparison struct {
+	Path     string
+	Operator string
+	Value    any
+}
+
+type Logical struct {
+	Operator string
+	Left     Expression
+	Right    Expression
+}
+
+type Not struct {
+	Expression Expression
+}
+
+func (Comparison) isExpression() {}
+func (Logical) isExpression()    {}
+func (Not) isExpression()        {}
+
+var comparisonOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// Parse parses a filter such as `userName eq "bjensen" and not (active eq false)`.
+func Parse(s string) (Expression, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, errorf("unexpected %q in filter", p.peek().text)
+	}
+	return expr, nil
+}
+
+// Path is a PATCH operation target such as `members[value eq "x"]` or
+// `emails[type eq "work"].value`.
+type Path struct {
+	Attribute    string
+	Filter       Expression
+	SubAttribute string
+}
+
+func ParsePath(s string) (Path, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return Path{}, err
+	}
+
+	if p.done() || p.peek().kind != tokenWord {
+		return Path{}, errorf("invalid path %q", s)
+	}
+	attribute := normalizeAttribute(p.next().text)
+	path := Path{Attribute: attribute}
+	if before, after, ok := strings.Cut(attribute, "."); ok {
+		path.Attribute, path.SubAttribute = before, after
+	}
+
+	if !p.done() && p.peek().kind == tokenOpenBracket {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return Path{}, err
+		}
+		if p.done() || p.next().kind != tokenCloseBracket {
+			return Path{}, errorf("missing ] in path %q", s)
+		}
+		path.Filter = inner
+
+		if !p.done() {
+			token := p.next()
+			if token.kind != tokenWord || !strings.HasPrefix(token.text, ".") {
+				return Path{}, errorf("invalid path %q", s)
+			}
+			path.SubAttribute = strings.ToLower(strings.TrimPrefix(token.text, "."))
+		}
+	}
+
+	if !p.done() {
+		return Path{}, errorf("invalid path %q", s)
+	}
+	return path, nil
+}
+
+// normalizeAttribute lower cases an attribute path and strips any schema URN,
+// since attribute names are case insensitive and only core schemas are
+// supported.
+func normalizeAttribute(attribute string) string {
+	if strings.HasPrefix(strings.ToLower(attribute), "urn:") {
+		if i := strings.LastIndex(attribute, ":"); i >= 0 {
+			attribute = attribute[i+1:]
+		}
+	}
+	return strings.ToLower(attribute)
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(s string) (*parser, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, errorf("unterminated string in filter")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, errorf("invalid string %s in filter", s[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: s[i:end]})
+			i = end
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, errorf("empty filter")
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	return !p.done() && p.peek().kind == tokenWord && strings.EqualFold(p.peek().text, keyword)
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Operator: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Operator: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expression, error) {
+	if p.done() {
+		return nil, errorf("unexpected end of filter")
+	}
+
+	if p.peekKeyword("not") {
+		p.next()
+		inner, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expression: inner}, nil
+	}
+	if p.peek().kind == tokenOpenParen {
+		return p.parseGroup()
+	}
+	return p.parseAttribute()
+}
+
+func (p *parser) parseGroup() (Expression, error) {
+	if p.done() || p.next().kind != tokenOpenParen {
+		return nil, errorf("expected ( in filter")
+	}
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.done() || p.next().kind != tokenCloseParen {
+		return nil, errorf("missing ) in filter")
+	}
+	return inner, nil
+}
+
+func (p *parser) parseAttribute() (Expression, error) {
+	attribute := p.next()
+	if attribute.kind != tokenWord {
+		return nil, errorf("expected attribute name, got %q", attribute.text)
+	}
+	path := normalizeAttribute(attribute.text)
+
+	// A value path like emails[type eq "work"] applies the inner filter to
+	// the sub-attributes of a multi-valued attribute
+	if !p.done() && p.peek().kind == tokenOpenBracket {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.next().kind != tokenCloseBracket {
+			return nil, errorf("missing ] in filter")
+		}
+		return prefixPaths(inner, path), nil
+	}
+
+	if p.done() || p.peek().kind != tokenWord {
+		return nil, errorf("expected operator after %q", attribute.text)
+	}
+	operator := strings.ToLower(p.next().text)
+	if operator == "pr" {
+		return Comparison{Path: path, Operator: operator}, nil
+	}
+	if !comparisonOperators[operator] {
+		return nil, errorf("unknown operator %q", operator)
+	}
+
+	if p.done() {
+		return nil, errorf("expected value after %q", operator)
+	}
+	value, err := parseValue(p.next())
+	if err != nil {
+		return nil, err
+	}
+	return Comparison{Path: path, Operator: operator, Value: value}, nil
+}
+
+func parseValue(t token) (any, error) {
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if n, err := strconv.ParseFloat(t.text, 64); err == nil {
+			return n, nil
+		}
+	}
+	return nil, errorf("invalid value %q in filter", t.text)
+}
+
+func prefixPaths(expr Expression, prefix string) Expression {
+	switch e := expr.(type) {
+	case Comparison:
+		e.Path = prefix + "." + e.Path
+		return e
+	case Logical:
+		e.Left = prefixPaths(e.Left, prefix)
+		e.Right = prefixPaths(e.Right, prefix)
+		return e
+	case Not:
+		e.Expression = prefixPaths(e.Expression, prefix)
+		return e
+	}
+	return expr
+}
@@ -0,0 +1,346 @@
+package scim
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/repositories"
+	"auth/internal/scim/filter"
+	"auth/internal/ulidutil"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Groups only contain users. Nested groups are rejected as invalid members.
+
+func (s *ScimService) CreateGroup(tenantID ulid.ULID, input GroupInput) (Group, error) {
+	group := model.Groups{
+		ID:          ulid.Make().Bytes(),
+		TenantID:    tenantID.Bytes(),
+		DisplayName: strings.TrimSpace(input.DisplayName),
+		ExternalID:  input.ExternalID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.validateGroup(group); err != nil {
+		return Group{}, err
+	}
+
+	memberIDs, err := s.resolveMembers(tenantID, input.Members)
+	if err != nil {
+		return Group{}, err
+	}
+
+	groupID := ulidutil.MustFromBytes(group.ID)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		groupRepo := repositories.NewGroupRepository(tx)
+		if err := groupRepo.Create(group); err != nil {
+			return err
+		}
+		return groupRepo.AddMembers(groupID, memberIDs)
+	})
+	if err != nil {
+		return Group{}, err
+	}
+
+	return s.GetGroup(tenantID, groupID)
+}
+
+func (s *ScimService) GetGroup(tenantID ulid.ULID, groupID ulid.ULID) (Group, error) {
+	group, err := s.groupRepo.GetByID(tenantID, groupID)
+	if err != nil {
+		return Group{}, err
+	}
+
+	members, err := s.groupRepo.ListMembers(groupID)
+	if err != nil {
+		return Group{}, err
+	}
+
+	return s.toGroup(*group, s.toMembers(members)), nil
+}
+
+func (s *ScimService) ListGroups(tenantID ulid.ULID, query ListQuery) (ListResponse[Group], error) {
+	groups, total, err := s.groupRepo.List(tenantID, query.Filter, query.StartIndex-1, query.Count)
+	if err != nil {
+		return ListResponse[Group]{}, err
+	}
+
+	membersByGroup := make(map[ulid.ULID][]Member)
+	if !query.ExcludeMembers {
+		groupIDs := make([]ulid.ULID, len(groups))
+		for i, group := range groups {
+			groupIDs[i] = ulidutil.MustFromBytes(group.ID)
+		}
+		members, err := s.groupRepo.ListMembers(groupIDs...)
+		if err != nil {
+			return ListResponse[Group]{}, err
+		}
+		for _, member := range members {
+			groupID := ulidutil.MustFromBytes(member.GroupID)
+			membersByGroup[groupID] = append(membersByGroup[groupID], s.toMembers([]repositories.GroupMember{member})...)
+		}
+	}
+
+	resources := make([]Group, len(groups))
+	for i, group := range groups {
+		resources[i] = s.toGroup(group, membersByGroup[ulidutil.MustFromBytes(group.ID)])
+	}
+
+	return ListResponse[Group]{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   query.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+func (s *ScimService) ReplaceGroup(tenantID ulid.ULID, groupID ulid.ULID, input GroupInput) (Group, error) {
+	if _, err := s.groupRepo.GetByID(tenantID, groupID); err != nil {
+		return Group{}, err
+	}
+
+	memberIDs, err := s.resolveMembers(tenantID, input.Members)
+	if err != nil {
+		return Group{}, err
+	}
+
+	return s.saveGroup(tenantID, groupID, func(group *model.Groups, _ []ulid.ULID) ([]ulid.ULID, error) {
+		group.DisplayName = strings.TrimSpace(input.DisplayName)
+		group.ExternalID = input.ExternalID
+		return memberIDs, nil
+	})
+}
+
+func (s *ScimService) PatchGroup(tenantID ulid.ULID, groupID ulid.ULID, patch PatchRequest) (Group, error) {
+	return s.saveGroup(tenantID, groupID, func(group *model.Groups, memberIDs []ulid.ULID) ([]ulid.ULID, error) {
+		err := applyPatch(patch, func(op string, path filter.Path, value any) error {
+			switch path.Attribute {
+			case "displayname":
+				name, ok := value.(string)
+				if op == "remove" || !ok || strings.TrimSpace(name) == "" {
+					return invalidValue("displayName must be a non-empty string")
+				}
+				group.DisplayName = strings.TrimSpace(name)
+			case "externalid":
+				if op == "remove" {
+					group.ExternalID = nil
+					return nil
+				}
+				externalID, ok := value.(string)
+				if !ok {
+					return invalidValue("externalId must be a string")
+				}
+				group.ExternalID = &externalID
+			case "members":
+				var err error
+				memberIDs, err = s.patchMembers(tenantID, memberIDs, op, path, value)
+				return err
+			}
+			return nil
+		})
+		return memberIDs, err
+	})
+}
+
+func (s *ScimService) DeleteGroup(tenantID ulid.ULID, groupID ulid.ULID) error {
+	if _, err := s.groupRepo.GetByID(tenantID, groupID); err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(groupID)
+}
+
+func (s *ScimService) validateGroup(group model.Groups) error {
+	if group.DisplayName == "" {
+		return invalidValue("displayName is required")
+	}
+
+	conflict, err := s.groupRepo.WillConflict(group)
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return newError(http.StatusConflict, "uniqueness", "displayName already in use")
+	}
+	return nil
+}
+
+// saveGroup locks the group and passes it with its current members to change,
+// then writes the group and brings its membership in line with the member
+// list change returns. Holding the lock throughout means concurrent changes
+// to the same group apply one after the other rather than overwriting each
+// other.
+func (s *ScimService) saveGroup(tenantID ulid.ULID, groupID ulid.ULID, change func(group *model.Groups, memberIDs []ulid.ULID) ([]ulid.ULID, error)) (Group, error) {
+	err := repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		groupRepo := repositories.NewGroupRepository(tx)
+		existing, err := groupRepo.GetByIDForUpdate(tenantID, groupID)
+		if err != nil {
+			return err
+		}
+
+		current, err := groupRepo.ListMembers(groupID)
+		if err != nil {
+			return err
+		}
+		currentIDs := make([]ulid.ULID, len(current))
+		for i, member := range current {
+			currentIDs[i] = ulidutil.MustFromBytes(member.UserID)
+		}
+
+		group := *existing
+		memberIDs, err := change(&group, slices.Clone(currentIDs))
+		if err != nil {
+			return err
+		}
+		if err := s.validateGroup(group); err != nil {
+			return err
+		}
+		if err := groupRepo.Update(group); err != nil {
+			return err
+		}
+
+		var removed []ulid.ULID
+		for _, userID := range currentIDs {
+			if !slices.Contains(memberIDs, userID) {
+				removed = append(removed, userID)
+			}
+		}
+		if err := groupRepo.RemoveMembers(groupID, removed); err != nil {
+			return err
+		}
+		return groupRepo.AddMembers(groupID, memberIDs)
+	})
+	if err != nil {
+		return Group{}, err
+	}
+
+	return s.GetGroup(tenantID, groupID)
+}
+
+// patchMembers applies a members operation to the current member list.
+func (s *ScimService) patchMembers(tenantID ulid.ULID, memberIDs []ulid.ULID, op string, path filter.Path, value any) ([]ulid.ULID, error) {
+	if op == "remove" {
+		// members[value eq "..."] names the members to remove, and some
+		// identity providers list them in the value instead
+		var targets []string
+		if path.Filter != nil {
+			values, ok := memberFilterValues(path.Filter)
+			if !ok {
+				return nil, newError(http.StatusBadRequest, "invalidFilter", "Only value eq filters are supported on members")
+			}
+			targets = values
+		} else if value != nil {
+			members, err := membersFromValue(value)
+			if err != nil {
+				return nil, err
+			}
+			for _, member := range members {
+				targets = append(targets, member.Value)
+			}
+		} else {
+			return nil, nil
+		}
+
+		return slices.DeleteFunc(memberIDs, func(id ulid.ULID) bool {
+			return slices.Contains(targets, ulidutil.ToPrefixed("user", id))
+		}), nil
+	}
+
+	members, err := membersFromValue(value)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := s.resolveMembers(tenantID, members)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "replace" {
+		return resolved, nil
+	}
+	for _, id := range resolved {
+		if !slices.Contains(memberIDs, id) {
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	return memberIDs, nil
+}
+
+// resolveMembers checks that every member is a user in the tenant.
+func (s *ScimService) resolveMembers(tenantID ulid.ULID, members []Member) ([]ulid.ULID, error) {
+	ids := make([]ulid.ULID, 0, len(members))
+	for _, member := range members {
+		id, err := ulidutil.FromPrefixed("user", member.Value)
+		if err != nil {
+			return nil, invalidValue("Member " + member.Value + " is not a user")
+		}
+		if _, err := s.getTenantUser(tenantID, id); err != nil {
+			return nil, invalidValue("Member " + member.Value + " is not a user in this tenant")
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *ScimService) toMembers(members []repositories.GroupMember) []Member {
+	result := make([]Member, len(members))
+	for i, member := range members {
+		id := ulidutil.ToPrefixed("user", ulidutil.MustFromBytes(member.UserID))
+		result[i] = Member{
+			Value:   id,
+			Display: member.Username,
+			Ref:     s.baseURL + "/Users/" + id,
+		}
+	}
+	return result
+}
+
+// membersFromValue accepts a single member object or a list of them.
+func membersFromValue(value any) ([]Member, error) {
+	var members []Member
+	if _, ok := value.([]any); ok {
+		if err := decodeValue(value, &members); err != nil {
+			return nil, invalidValue("members must be a list of member objects")
+		}
+		return members, nil
+	}
+
+	var member Member
+	if err := decodeValue(value, &member); err != nil || member.Value == "" {
+		return nil, invalidValue("members must be a member object")
+	}
+	return []Member{member}, nil
+}
+
+// memberFilterValues extracts the IDs from filters like
+// `value eq "a" or value eq "b"`.
+func memberFilterValues(expr filter.Expression) ([]string, bool) {
+	switch e := expr.(type) {
+	case filter.Comparison:
+		value, ok := e.Value.(string)
+		if e.Path != "value" || e.Operator != "eq" || !ok {
+			return nil, false
+		}
+		return []string{value}, true
+	case filter.Logical:
+		if e.Operator != "or" {
+			return nil, false
+		}
+		left, ok := memberFilterValues(e.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := memberFilterValues(e.Right)
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	}
+	return nil, false
+}
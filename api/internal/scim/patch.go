@@ -0,0 +1,80 @@
+package scim
+
+import (
+	"auth/internal/scim/filter"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// applyPatch normalises each operation and passes it to apply one attribute
+// at a time. Operations without a path carry an object of attribute values,
+// which is how several identity providers send replacements.
+func applyPatch(patch PatchRequest, apply func(op string, path filter.Path, value any) error) error {
+	if len(patch.Operations) == 0 {
+		return invalidValue("Operations is required")
+	}
+
+	for _, operation := range patch.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return invalidValue("Unknown op " + operation.Op)
+		}
+
+		if operation.Path != "" {
+			path, err := filter.ParsePath(operation.Path)
+			if err != nil {
+				return newError(http.StatusBadRequest, "invalidPath", err.Error())
+			}
+			if err := apply(op, path, operation.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if op == "remove" {
+			return newError(http.StatusBadRequest, "noTarget", "remove requires a path")
+		}
+		values, ok := operation.Value.(map[string]any)
+		if !ok {
+			return invalidValue("value must be an object when no path is given")
+		}
+		for attribute, value := range values {
+			path, err := filter.ParsePath(attribute)
+			if err != nil {
+				return newError(http.StatusBadRequest, "invalidPath", err.Error())
+			}
+			if err := apply(op, path, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// asBool also accepts the "True" and "False" strings some identity providers
+// send in place of JSON booleans.
+func asBool(value any) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// decodeValue converts a loosely typed PATCH value into dst.
+func decodeValue(value any, dst any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, dst)
+}
@@ -0,0 +1,61 @@
+package scim
+
+import (
+	"auth/internal/scim/filter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCount = 100
+	maxCount     = 200
+)
+
+type ListQuery struct {
+	Filter     filter.Expression
+	StartIndex int64
+	Count      int64
+	// ExcludeMembers skips loading group members, which identity providers
+	// request when they only need to find a group
+	ExcludeMembers bool
+}
+
+// ParseListQuery reads the filter, startIndex, count and excludedAttributes
+// parameters. startIndex is 1-based and values below 1 are treated as 1.
+func ParseListQuery(query url.Values) (ListQuery, error) {
+	list := ListQuery{StartIndex: 1, Count: defaultCount}
+
+	if value := query.Get("filter"); value != "" {
+		expr, err := filter.Parse(value)
+		if err != nil {
+			return list, newError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		list.Filter = expr
+	}
+
+	if value := query.Get("startIndex"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return list, invalidValue("startIndex must be an integer")
+		}
+		list.StartIndex = max(n, 1)
+	}
+
+	if value := query.Get("count"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return list, invalidValue("count must be an integer")
+		}
+		list.Count = min(max(n, 0), maxCount)
+	}
+
+	for _, attribute := range strings.Split(query.Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			list.ExcludeMembers = true
+		}
+	}
+
+	return list, nil
+}
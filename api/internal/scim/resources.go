@@ -0,0 +1,186 @@
+package scim
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/ulidutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// Error is a SCIM error response. ScimType is one of the detail error keywords
+// from RFC 7644 section 3.12, such as "uniqueness" or "invalidFilter".
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func newError(status int, scimType string, detail string) *Error {
+	return &Error{Status: status, ScimType: scimType, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+func (e *Error) Body() any {
+	return struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{[]string{SchemaError}, fmt.Sprint(e.Status), e.ScimType, e.Detail}
+}
+
+func invalidValue(detail string) *Error {
+	return newError(http.StatusBadRequest, "invalidValue", detail)
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+	Version      string    `json:"version"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type User struct {
+	Schemas    []string `json:"schemas"`
+	ID         string   `json:"id"`
+	ExternalID *string  `json:"externalId,omitempty"`
+	UserName   string   `json:"userName"`
+	Emails     []Email  `json:"emails"`
+	Active     bool     `json:"active"`
+	Meta       Meta     `json:"meta"`
+}
+
+// UserInput is the body of a create or replace request. Attributes that
+// aren't stored, like name or title, are accepted and ignored.
+type UserInput struct {
+	ExternalID *string `json:"externalId"`
+	UserName   string  `json:"userName"`
+	Emails     []Email `json:"emails"`
+	Active     *bool   `json:"active"`
+	Password   string  `json:"password"`
+}
+
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	ExternalID  *string  `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+	Meta        Meta     `json:"meta"`
+}
+
+type GroupInput struct {
+	ExternalID  *string  `json:"externalId"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+}
+
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int64    `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// primaryEmail picks the address marked primary, or the first one.
+func primaryEmail(emails []Email) string {
+	for _, email := range emails {
+		if email.Primary {
+			return strings.TrimSpace(email.Value)
+		}
+	}
+	if len(emails) > 0 {
+		return strings.TrimSpace(emails[0].Value)
+	}
+	return ""
+}
+
+// version changes whenever the resource is modified, for use as an ETag.
+func version(updatedAt time.Time) string {
+	return fmt.Sprintf(`W/"%d"`, updatedAt.UnixNano())
+}
+
+func (s *ScimService) toUser(user model.Users) User {
+	id := ulidutil.ToPrefixed("user", ulidutil.MustFromBytes(user.ID))
+	return User{
+		Schemas:    []string{SchemaUser},
+		ID:         id,
+		ExternalID: user.ExternalID,
+		UserName:   user.Username,
+		Emails:     []Email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:     user.Active,
+		Meta: Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     s.baseURL + "/Users/" + id,
+			Version:      version(user.UpdatedAt),
+		},
+	}
+}
+
+func (s *ScimService) toGroup(group model.Groups, members []Member) Group {
+	id := ulidutil.ToPrefixed("group", ulidutil.MustFromBytes(group.ID))
+	if members == nil {
+		members = []Member{}
+	}
+	return Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          id,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     members,
+		Meta: Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     s.baseURL + "/Groups/" + id,
+			Version:      version(group.UpdatedAt),
+		},
+	}
+}
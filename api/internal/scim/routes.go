@@ -0,0 +1,320 @@
+package scim
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/httputil"
+	"auth/internal/middleware"
+	"auth/internal/scim/filter"
+	"auth/internal/ulidutil"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+)
+
+func Router(s *ScimService) http.Handler {
+	r := chi.NewRouter()
+
+	// Discovery documents are static, so clients may read them before
+	// they have a token
+	r.Get("/ServiceProviderConfig", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.ServiceProviderConfig())
+	})
+
+	r.Get("/ResourceTypes", func(w http.ResponseWriter, r *http.Request) {
+		resourceTypes := s.ResourceTypes()
+		writeJSON(w, http.StatusOK, ListResponse[ResourceType]{
+			Schemas:      []string{SchemaListResponse},
+			TotalResults: int64(len(resourceTypes)),
+			StartIndex:   1,
+			ItemsPerPage: len(resourceTypes),
+			Resources:    resourceTypes,
+		})
+	})
+
+	r.Get("/ResourceTypes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		resourceType, err := s.ResourceType(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resourceType)
+	})
+
+	r.Get("/Schemas", func(w http.ResponseWriter, r *http.Request) {
+		schemas := s.Schemas()
+		writeJSON(w, http.StatusOK, ListResponse[Schema]{
+			Schemas:      []string{SchemaListResponse},
+			TotalResults: int64(len(schemas)),
+			StartIndex:   1,
+			ItemsPerPage: len(schemas),
+			Resources:    schemas,
+		})
+	})
+
+	r.Get("/Schemas/{id}", func(w http.ResponseWriter, r *http.Request) {
+		schema, err := s.Schema(chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, schema)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.ScimAuth(s.scimTokenRepo))
+
+		r.Post("/Users", func(w http.ResponseWriter, r *http.Request) {
+			var body UserInput
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			user, err := s.CreateUser(tenantID(r), body, httputil.GetRequestMeta(r))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			w.Header().Set("Location", user.Meta.Location)
+			writeJSON(w, http.StatusCreated, user)
+		})
+
+		r.Get("/Users", func(w http.ResponseWriter, r *http.Request) {
+			query, err := ParseListQuery(r.URL.Query())
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			response, err := s.ListUsers(tenantID(r), query)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, response)
+		})
+
+		r.Get("/Users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := resourceID(w, r, "user")
+			if !ok {
+				return
+			}
+
+			user, err := s.GetUser(tenantID(r), userID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, user)
+		})
+
+		r.Put("/Users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := resourceID(w, r, "user")
+			if !ok {
+				return
+			}
+			var body UserInput
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			user, err := s.ReplaceUser(tenantID(r), userID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, user)
+		})
+
+		r.Patch("/Users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := resourceID(w, r, "user")
+			if !ok {
+				return
+			}
+			var body PatchRequest
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			user, err := s.PatchUser(tenantID(r), userID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, user)
+		})
+
+		r.Delete("/Users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := resourceID(w, r, "user")
+			if !ok {
+				return
+			}
+
+			err := s.DeleteUser(tenantID(r), userID, httputil.GetRequestMeta(r))
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.Post("/Groups", func(w http.ResponseWriter, r *http.Request) {
+			var body GroupInput
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			group, err := s.CreateGroup(tenantID(r), body)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			w.Header().Set("Location", group.Meta.Location)
+			writeJSON(w, http.StatusCreated, group)
+		})
+
+		r.Get("/Groups", func(w http.ResponseWriter, r *http.Request) {
+			query, err := ParseListQuery(r.URL.Query())
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			response, err := s.ListGroups(tenantID(r), query)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, response)
+		})
+
+		r.Get("/Groups/{id}", func(w http.ResponseWriter, r *http.Request) {
+			groupID, ok := resourceID(w, r, "group")
+			if !ok {
+				return
+			}
+
+			group, err := s.GetGroup(tenantID(r), groupID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, group)
+		})
+
+		r.Put("/Groups/{id}", func(w http.ResponseWriter, r *http.Request) {
+			groupID, ok := resourceID(w, r, "group")
+			if !ok {
+				return
+			}
+			var body GroupInput
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			group, err := s.ReplaceGroup(tenantID(r), groupID, body)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, group)
+		})
+
+		r.Patch("/Groups/{id}", func(w http.ResponseWriter, r *http.Request) {
+			groupID, ok := resourceID(w, r, "group")
+			if !ok {
+				return
+			}
+			var body PatchRequest
+			if !parseBody(w, r, &body) {
+				return
+			}
+
+			group, err := s.PatchGroup(tenantID(r), groupID, body)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, group)
+		})
+
+		r.Delete("/Groups/{id}", func(w http.ResponseWriter, r *http.Request) {
+			groupID, ok := resourceID(w, r, "group")
+			if !ok {
+				return
+			}
+
+			err := s.DeleteGroup(tenantID(r), groupID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	return r
+}
+
+func tenantID(r *http.Request) ulid.ULID {
+	return r.Context().Value(middleware.ScimTenantContextKey).(ulid.ULID)
+}
+
+// resourceID parses the {id} URL parameter. IDs that can't exist are reported
+// as not found, as SCIM clients expect.
+func resourceID(w http.ResponseWriter, r *http.Request, prefix string) (ulid.ULID, bool) {
+	id, err := ulidutil.FromPrefixed(prefix, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, newError(http.StatusNotFound, "", "Resource not found"))
+		return ulid.Zero, false
+	}
+	return id, true
+}
+
+func parseBody(w http.ResponseWriter, r *http.Request, body any) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, newError(http.StatusBadRequest, "invalidSyntax", "Request body is not valid JSON"))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError renders any error in the SCIM error format.
+func writeError(w http.ResponseWriter, err error) {
+	var scimErr *Error
+	var filterErr *filter.Error
+	var httpErr apperror.HTTPError
+
+	switch {
+	case errors.As(err, &scimErr):
+	case errors.As(err, &filterErr):
+		scimErr = newError(http.StatusBadRequest, "invalidFilter", filterErr.Message)
+	case errors.As(err, &httpErr):
+		scimErr = newError(httpErr.StatusCode(), "", strings.TrimSpace(httpErr.Error()))
+	default:
+		scimErr = newError(http.StatusInternalServerError, "", "Internal server error")
+	}
+
+	writeJSON(w, scimErr.Status, scimErr.Body())
+}
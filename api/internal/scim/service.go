@@ -0,0 +1,46 @@
+package scim
+
+import (
+	"auth/internal/audit"
+	"auth/internal/passwordhash"
+	"auth/internal/passwordpolicy"
+	"auth/internal/repositories"
+	"auth/internal/webhooks"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type ScimService struct {
+	db                *sql.DB
+	baseURL           string
+	passwordHasher    *passwordhash.Hasher
+	passwordPolicy    *passwordpolicy.Policy
+	auditLogger       *audit.Logger
+	webhookDispatcher *webhooks.Dispatcher
+	tombstonePeriod   time.Duration
+
+	userRepo             repositories.UserRepository
+	groupRepo            repositories.GroupRepository
+	scimTokenRepo        repositories.ScimTokenRepository
+	accountTombstoneRepo repositories.AccountTombstoneRepository
+}
+
+// NewScimService takes the public URL of this service, which resource
+// locations are built from, and how long the email and username of a deleted
+// user stay reserved.
+func NewScimService(db *sql.DB, publicURL string, passwordHasher *passwordhash.Hasher, passwordPolicy *passwordpolicy.Policy, auditLogger *audit.Logger, webhookDispatcher *webhooks.Dispatcher, tombstonePeriod time.Duration) (*ScimService, error) {
+	return &ScimService{
+		db:                   db,
+		baseURL:              strings.TrimSuffix(publicURL, "/") + "/scim/v2",
+		passwordHasher:       passwordHasher,
+		passwordPolicy:       passwordPolicy,
+		auditLogger:          auditLogger,
+		webhookDispatcher:    webhookDispatcher,
+		tombstonePeriod:      tombstonePeriod,
+		userRepo:             repositories.NewUserRepository(db),
+		groupRepo:            repositories.NewGroupRepository(db),
+		scimTokenRepo:        repositories.NewScimTokenRepository(db),
+		accountTombstoneRepo: repositories.NewAccountTombstoneRepository(db),
+	}, nil
+}
@@ -0,0 +1,404 @@
+package scim
+
+import (
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/emails"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/passwordpolicy"
+	"auth/internal/repositories"
+	"auth/internal/scim/filter"
+	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+func (s *ScimService) CreateUser(tenantID ulid.ULID, input UserInput, meta httputil.RequestMeta) (User, error) {
+	scimTenantID := tenantID.Bytes()
+	user := model.Users{
+		ID:         ulid.Make().Bytes(),
+		Username:   strings.TrimSpace(input.UserName),
+		Email:      primaryEmail(input.Emails),
+		ExternalID: input.ExternalID,
+		// Addresses managed by the customer's identity provider are trusted
+		EmailVerified: true,
+		Active:        true,
+		ScimTenantID:  &scimTenantID,
+		Locale:        emails.DefaultLocale,
+	}
+	if input.Active != nil {
+		user.Active = *input.Active
+	}
+	// Many identity providers use the email address as the user name
+	if user.Email == "" && strings.Contains(user.Username, "@") {
+		user.Email = user.Username
+	}
+
+	if err := s.validateUser(user, nil); err != nil {
+		return User{}, err
+	}
+
+	if input.Password != "" {
+		hash, err := s.hashPassword(input.Password, user, nil)
+		if err != nil {
+			return User{}, err
+		}
+		user.PasswordHash = hash
+	}
+
+	userID := ulidutil.MustFromBytes(user.ID)
+	err := repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.Create(user); err != nil {
+			return err
+		}
+
+		if user.PasswordHash == "" {
+			return nil
+		}
+		return s.passwordPolicy.Remember(tx, userID, user.PasswordHash)
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventScimUserProvisioned,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"tenant_id": ulidutil.ToPrefixed("tenant", tenantID)},
+	})
+
+	return s.GetUser(tenantID, userID)
+}
+
+func (s *ScimService) GetUser(tenantID ulid.ULID, userID ulid.ULID) (User, error) {
+	user, err := s.getTenantUser(tenantID, userID)
+	if err != nil {
+		return User{}, err
+	}
+	return s.toUser(*user), nil
+}
+
+func (s *ScimService) ListUsers(tenantID ulid.ULID, query ListQuery) (ListResponse[User], error) {
+	users, total, err := s.userRepo.ListByScimTenant(tenantID, query.Filter, query.StartIndex-1, query.Count)
+	if err != nil {
+		return ListResponse[User]{}, err
+	}
+
+	resources := make([]User, len(users))
+	for i, user := range users {
+		resources[i] = s.toUser(user)
+	}
+
+	return ListResponse[User]{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   query.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+func (s *ScimService) ReplaceUser(tenantID ulid.ULID, userID ulid.ULID, input UserInput, meta httputil.RequestMeta) (User, error) {
+	existing, err := s.getTenantUser(tenantID, userID)
+	if err != nil {
+		return User{}, err
+	}
+
+	user := *existing
+	user.Username = strings.TrimSpace(input.UserName)
+	user.Email = primaryEmail(input.Emails)
+	user.ExternalID = input.ExternalID
+	if user.Email == "" && strings.Contains(user.Username, "@") {
+		user.Email = user.Username
+	}
+	if input.Active != nil {
+		user.Active = *input.Active
+	}
+
+	return s.saveUser(tenantID, *existing, user, input.Password, meta)
+}
+
+func (s *ScimService) PatchUser(tenantID ulid.ULID, userID ulid.ULID, patch PatchRequest, meta httputil.RequestMeta) (User, error) {
+	existing, err := s.getTenantUser(tenantID, userID)
+	if err != nil {
+		return User{}, err
+	}
+
+	user := *existing
+	var password string
+	err = applyPatch(patch, func(op string, path filter.Path, value any) error {
+		return applyUserAttribute(&user, &password, op, path, value)
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return s.saveUser(tenantID, *existing, user, password, meta)
+}
+
+// DeleteUser removes the user outright, reserving their email and username
+// like a purged account. Identity providers that only want to suspend access
+// set active to false instead.
+func (s *ScimService) DeleteUser(tenantID ulid.ULID, userID ulid.ULID, meta httputil.RequestMeta) error {
+	user, err := s.getTenantUser(tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	tombstoneExpiresAt := time.Now().Add(s.tombstonePeriod)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		if err := refreshTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.Delete(userID); err != nil {
+			return err
+		}
+
+		accountTombstoneRepo := repositories.NewAccountTombstoneRepository(tx)
+		if err := accountTombstoneRepo.Create(repositories.AccountTombstoneEmail, user.Email, tombstoneExpiresAt); err != nil {
+			return err
+		}
+		if err := accountTombstoneRepo.Create(repositories.AccountTombstoneUsername, user.Username, tombstoneExpiresAt); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserDeleted,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventScimUserDeleted,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"tenant_id": ulidutil.ToPrefixed("tenant", tenantID)},
+	})
+
+	return nil
+}
+
+// getTenantUser hides users from other tenants, and those that signed up
+// directly, behind a 404.
+func (s *ScimService) getTenantUser(tenantID ulid.ULID, userID ulid.ULID) (*model.Users, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ScimTenantID == nil || ulidutil.MustFromBytes(*user.ScimTenantID) != tenantID {
+		return nil, newError(http.StatusNotFound, "", "User not found")
+	}
+	return user, nil
+}
+
+// validateUser checks a user being created, or updated from existing.
+func (s *ScimService) validateUser(user model.Users, existing *model.Users) error {
+	if user.Username == "" {
+		return invalidValue("userName is required")
+	}
+	address, err := mail.ParseAddress(user.Email)
+	if err != nil || address.Address != user.Email {
+		return invalidValue("A valid email address is required")
+	}
+
+	conflict, err := s.userRepo.WillConflict(user)
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return newError(http.StatusConflict, "uniqueness", "userName or email already in use")
+	}
+
+	// The addresses and usernames of recently deleted accounts stay reserved
+	reserved := false
+	if existing == nil || existing.Email != user.Email {
+		if reserved, err = s.accountTombstoneRepo.Exists(repositories.AccountTombstoneEmail, user.Email); err != nil {
+			return err
+		}
+	}
+	if !reserved && (existing == nil || existing.Username != user.Username) {
+		if reserved, err = s.accountTombstoneRepo.Exists(repositories.AccountTombstoneUsername, user.Username); err != nil {
+			return err
+		}
+	}
+	if reserved {
+		return newError(http.StatusConflict, "uniqueness", "userName or email already in use")
+	}
+	return nil
+}
+
+// saveUser writes the changes from a replace or patch, revoking sessions when
+// the user is deactivated or their password is changed.
+func (s *ScimService) saveUser(tenantID ulid.ULID, before model.Users, after model.Users, password string, meta httputil.RequestMeta) (User, error) {
+	if err := s.validateUser(after, &before); err != nil {
+		return User{}, err
+	}
+
+	var hash string
+	if password != "" {
+		var err error
+		hash, err = s.hashPassword(password, after, &before)
+		if err != nil {
+			return User{}, err
+		}
+	}
+
+	userID := ulidutil.MustFromBytes(after.ID)
+	deactivated := before.Active && !after.Active
+	err := repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.UpdateProvisioned(after); err != nil {
+			return err
+		}
+
+		if hash != "" {
+			if err := userRepo.SetPassword(userID, hash); err != nil {
+				return err
+			}
+			if err := s.passwordPolicy.Remember(tx, userID, hash); err != nil {
+				return err
+			}
+		}
+
+		if !deactivated && hash == "" {
+			return nil
+		}
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		return refreshTokenRepo.RevokeByUserID(userID)
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	eventType := audit.EventScimUserUpdated
+	if deactivated {
+		eventType = audit.EventScimUserDeactivated
+	}
+	s.auditLogger.Record(audit.Event{
+		Type:     eventType,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"tenant_id": ulidutil.ToPrefixed("tenant", tenantID)},
+	})
+
+	return s.GetUser(tenantID, userID)
+}
+
+// hashPassword applies the password policy to a password set by the identity
+// provider, reporting broken rules as an invalidValue error. existing is nil
+// when the user is being created.
+func (s *ScimService) hashPassword(password string, user model.Users, existing *model.Users) (string, error) {
+	_, err := s.passwordPolicy.Validate(passwordpolicy.Candidate{
+		Password: password,
+		Username: user.Username,
+		Email:    user.Email,
+		User:     existing,
+	})
+	var validationErr *apperror.ValidationError
+	if errors.As(err, &validationErr) {
+		messages := make([]string, len(validationErr.Violations))
+		for i, violation := range validationErr.Violations {
+			messages[i] = violation.Message
+		}
+		return "", invalidValue(strings.Join(messages, "; "))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return s.passwordHasher.Hash(password)
+}
+
+// applyUserAttribute applies one PATCH operation. Attributes that aren't
+// stored, such as name or title, are ignored so that identity providers with
+// richer default mappings can still provision users.
+func applyUserAttribute(user *model.Users, password *string, op string, path filter.Path, value any) error {
+	remove := op == "remove"
+
+	switch path.Attribute {
+	case "username":
+		s, ok := value.(string)
+		if remove || !ok || strings.TrimSpace(s) == "" {
+			return invalidValue("userName must be a non-empty string")
+		}
+		user.Username = strings.TrimSpace(s)
+	case "active":
+		b, ok := asBool(value)
+		if remove || !ok {
+			return invalidValue("active must be a boolean")
+		}
+		user.Active = b
+	case "externalid":
+		if remove {
+			user.ExternalID = nil
+			return nil
+		}
+		s, ok := value.(string)
+		if !ok {
+			return invalidValue("externalId must be a string")
+		}
+		user.ExternalID = &s
+	case "emails":
+		if path.SubAttribute != "" && path.SubAttribute != "value" {
+			return nil
+		}
+		if remove {
+			return invalidValue("emails is required")
+		}
+		email, err := emailFromValue(value)
+		if err != nil {
+			return err
+		}
+		user.Email = email
+	case "password":
+		s, ok := value.(string)
+		if remove || !ok || s == "" {
+			return invalidValue("password must be a non-empty string")
+		}
+		*password = s
+	}
+	return nil
+}
+
+// emailFromValue accepts a bare address, an email object or a list of them.
+func emailFromValue(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s), nil
+	}
+
+	var emails []Email
+	if _, ok := value.([]any); ok {
+		if err := decodeValue(value, &emails); err != nil {
+			return "", invalidValue("emails must be a list of email objects")
+		}
+	} else {
+		var email Email
+		if err := decodeValue(value, &email); err != nil {
+			return "", invalidValue("emails must be an email object")
+		}
+		emails = []Email{email}
+	}
+
+	email := primaryEmail(emails)
+	if email == "" {
+		return "", invalidValue("emails must contain an address")
+	}
+	return email, nil
+}
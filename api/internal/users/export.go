@@ -0,0 +1,450 @@
+package users
+
+import (
+	"archive/zip"
+	"auth/internal/apperror"
+	"auth/internal/audit"
+	"auth/internal/auth"
+	"auth/internal/emails"
+	"auth/internal/httputil"
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/outbox"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// ExportOutboxKind identifies data exports waiting in the outbox to be
+// assembled.
+const ExportOutboxKind = "data_export"
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// How long an export may stay pending before it is considered abandoned and
+// the user can ask for another. Generous, as the outbox backs off between
+// retries.
+const exportPendingTimeout = 24 * time.Hour
+
+// Exporter assembles the personal data archives users ask for outside the
+// request that asked for them, and emails a single-use link to download them.
+type Exporter struct {
+	db           *sql.DB
+	linkTTL      time.Duration
+	outbox       *outbox.Outbox
+	emailService *emails.EmailService
+	auditLogger  *audit.Logger
+
+	userRepo               repositories.UserRepository
+	refreshTokenRepo       repositories.RefreshTokenRepository
+	emailChangeRequestRepo repositories.EmailChangeRequestRepository
+	emailMessageRepo       repositories.EmailMessageRepository
+	groupRepo              repositories.GroupRepository
+	scimTenantRepo         repositories.ScimTenantRepository
+	dataExportRepo         repositories.DataExportRepository
+}
+
+func NewExporter(db *sql.DB, linkTTL time.Duration, outbox *outbox.Outbox, emailService *emails.EmailService, auditLogger *audit.Logger) *Exporter {
+	return &Exporter{
+		db:           db,
+		linkTTL:      linkTTL,
+		outbox:       outbox,
+		emailService: emailService,
+		auditLogger:  auditLogger,
+
+		userRepo:               repositories.NewUserRepository(db),
+		refreshTokenRepo:       repositories.NewRefreshTokenRepository(db),
+		emailChangeRequestRepo: repositories.NewEmailChangeRequestRepository(db),
+		emailMessageRepo:       repositories.NewEmailMessageRepository(db),
+		groupRepo:              repositories.NewGroupRepository(db),
+		scimTenantRepo:         repositories.NewScimTenantRepository(db),
+		dataExportRepo:         repositories.NewDataExportRepository(db),
+	}
+}
+
+type exportMessage struct {
+	ExportID string `json:"export_id"`
+}
+
+type RequestExportParams struct {
+	// json or zip, json if empty
+	Format string `json:"format"`
+}
+
+type RequestExportResponse struct {
+	ID        string    `json:"id"`
+	Format    string    `json:"format"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestExport queues an archive of the user's personal data to be
+// assembled. The user is emailed a download link once it is ready.
+func (s *UsersService) RequestExport(userID ulid.ULID, params RequestExportParams, meta httputil.RequestMeta) (RequestExportResponse, error) {
+	format := params.Format
+	if format == "" {
+		format = ExportFormatJSON
+	}
+	if format != ExportFormatJSON && format != ExportFormatZIP {
+		return RequestExportResponse{}, apperror.NewBadRequest("Invalid format, expected json or zip")
+	}
+
+	active, err := s.exporter.dataExportRepo.GetActiveByUserID(userID, time.Now().Add(-exportPendingTimeout))
+	if err != nil {
+		return RequestExportResponse{}, err
+	}
+	if active != nil {
+		return RequestExportResponse{}, apperror.NewConflict("Data export already in progress")
+	}
+
+	exportID := ulid.Make()
+	dataExportModel := model.DataExports{
+		ID:        exportID.Bytes(),
+		UserID:    userID.Bytes(),
+		Format:    format,
+		Status:    repositories.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		dataExportRepo := repositories.NewDataExportRepository(tx)
+		if err := dataExportRepo.Create(dataExportModel); err != nil {
+			return err
+		}
+		return s.exporter.outbox.Enqueue(tx, ExportOutboxKind, exportMessage{ExportID: exportID.String()})
+	})
+	if err != nil {
+		return RequestExportResponse{}, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventDataExportRequested,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"export_id": ulidutil.ToPrefixed("export", exportID), "format": format},
+	})
+
+	return RequestExportResponse{
+		ID:        ulidutil.ToPrefixed("export", exportID),
+		Format:    format,
+		Status:    dataExportModel.Status,
+		CreatedAt: dataExportModel.CreatedAt,
+	}, nil
+}
+
+type DownloadExportParams struct {
+	Token string `json:"token"`
+}
+
+// Archive is a finished export ready to be sent to the user.
+type Archive struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// DownloadExport returns the archive the token was emailed for. The token
+// works once, the archive is discarded after it is handed out.
+func (s *UsersService) DownloadExport(userID ulid.ULID, params DownloadExportParams, meta httputil.RequestMeta) (Archive, error) {
+	token, err := auth.URLDecodeToken(params.Token)
+	if err != nil {
+		return Archive{}, apperror.NewBadRequest("Invalid token")
+	}
+
+	export, err := s.exporter.dataExportRepo.ClaimDownload(userID, auth.HashToken(token))
+	if err != nil {
+		return Archive{}, err
+	}
+	if export == nil || export.Archive == nil {
+		return Archive{}, apperror.NewBadRequest("Invalid token")
+	}
+
+	exportID := ulidutil.MustFromBytes(export.ID)
+	if err := s.exporter.dataExportRepo.DiscardArchive(exportID); err != nil {
+		return Archive{}, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventDataExportDownloaded,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"export_id": ulidutil.ToPrefixed("export", exportID)},
+	})
+
+	archive := Archive{
+		Filename:    "data-export-" + export.CreatedAt.UTC().Format("2006-01-02") + "." + export.Format,
+		ContentType: "application/json",
+		Data:        *export.Archive,
+	}
+	if export.Format == ExportFormatZIP {
+		archive.ContentType = "application/zip"
+	}
+	return archive, nil
+}
+
+// Build assembles a queued export and emails its download link. It is the
+// outbox handler for ExportOutboxKind.
+func (e *Exporter) Build(messageID ulid.ULID, payload []byte) error {
+	var message exportMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return fmt.Errorf("failed to decode data export: %w", err)
+	}
+	exportID, err := ulid.Parse(message.ExportID)
+	if err != nil {
+		return fmt.Errorf("invalid data export ID %q: %w", message.ExportID, err)
+	}
+
+	export, err := e.dataExportRepo.GetByID(exportID)
+	if err != nil {
+		return err
+	}
+	// Deleted along with its user, or built by an earlier attempt
+	if export == nil || export.Status != repositories.DataExportPending {
+		return nil
+	}
+
+	userID := ulidutil.MustFromBytes(export.UserID)
+	user, err := e.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	contents, err := e.collect(user)
+	if err != nil {
+		return err
+	}
+
+	var archive []byte
+	if export.Format == ExportFormatZIP {
+		archive, err = contents.zip()
+	} else {
+		archive, err = json.MarshalIndent(contents, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode data export: %w", err)
+	}
+
+	expiresAt := time.Now().Add(e.linkTTL)
+	token, hashedToken := auth.GenerateResetToken()
+	return repositories.WithTx(e.db, func(tx *sql.Tx) error {
+		dataExportRepo := repositories.NewDataExportRepository(tx)
+		completed, err := dataExportRepo.Complete(exportID, archive, hashedToken, expiresAt)
+		if err != nil || !completed {
+			return err
+		}
+
+		return e.emailService.SendDataExportReadyEmail(tx, userID, user.Email, user.Username, user.Locale, auth.URLEncodeToken(token), expiresAt)
+	})
+}
+
+// DiscardExpired drops the archives of exports whose link expired unused and
+// returns how many it dropped.
+func (e *Exporter) DiscardExpired() (int64, error) {
+	return e.dataExportRepo.DiscardExpired()
+}
+
+type exportContents struct {
+	ExportedAt   time.Time             `json:"exported_at"`
+	Profile      exportProfile         `json:"profile"`
+	Sessions     []exportSession       `json:"sessions"`
+	AuditEvents  []audit.EventResponse `json:"audit_events"`
+	Identities   []exportIdentity      `json:"identities"`
+	EmailHistory exportEmailHistory    `json:"email_history"`
+}
+
+type exportProfile struct {
+	ID                  string     `json:"id"`
+	Email               string     `json:"email"`
+	Username            string     `json:"username"`
+	EmailVerified       bool       `json:"email_verified"`
+	IsAdmin             bool       `json:"is_admin"`
+	Active              bool       `json:"active"`
+	Locale              string     `json:"locale"`
+	FailedLoginAttempts int32      `json:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `json:"last_failed_login_at"`
+	LockedUntil         *time.Time `json:"locked_until"`
+	DeleteAfter         *time.Time `json:"delete_after"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type exportSession struct {
+	ID        string     `json:"id"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// exportIdentity is an account at an identity provider that the user is
+// provisioned from.
+type exportIdentity struct {
+	Provider   string   `json:"provider"`
+	Tenant     string   `json:"tenant"`
+	ExternalID *string  `json:"external_id"`
+	Groups     []string `json:"groups"`
+}
+
+type exportEmailHistory struct {
+	Changes  []exportEmailChange  `json:"changes"`
+	Messages []exportEmailMessage `json:"messages"`
+}
+
+type exportEmailChange struct {
+	PreviousEmail string     `json:"previous_email"`
+	NewEmail      string     `json:"new_email"`
+	ConfirmedAt   *time.Time `json:"confirmed_at"`
+	CancelledAt   *time.Time `json:"cancelled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type exportEmailMessage struct {
+	Template  string    `json:"template"`
+	Recipient string    `json:"recipient"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (e *Exporter) collect(user *model.Users) (exportContents, error) {
+	userID := ulidutil.MustFromBytes(user.ID)
+	contents := exportContents{
+		ExportedAt: time.Now(),
+		Profile: exportProfile{
+			ID:                  ulidutil.ToPrefixed("user", userID),
+			Email:               user.Email,
+			Username:            user.Username,
+			EmailVerified:       user.EmailVerified,
+			IsAdmin:             user.IsAdmin,
+			Active:              user.Active,
+			Locale:              user.Locale,
+			FailedLoginAttempts: user.FailedLoginAttempts,
+			LastFailedLoginAt:   user.LastFailedLoginAt,
+			LockedUntil:         user.LockedUntil,
+			DeleteAfter:         user.DeleteAfter,
+			CreatedAt:           user.CreatedAt,
+			UpdatedAt:           user.UpdatedAt,
+		},
+		Sessions:   []exportSession{},
+		Identities: []exportIdentity{},
+		EmailHistory: exportEmailHistory{
+			Changes:  []exportEmailChange{},
+			Messages: []exportEmailMessage{},
+		},
+	}
+
+	refreshTokens, err := e.refreshTokenRepo.ListByUserID(userID)
+	if err != nil {
+		return exportContents{}, err
+	}
+	for _, token := range refreshTokens {
+		contents.Sessions = append(contents.Sessions, exportSession{
+			ID:        ulidutil.ToPrefixed("session", ulidutil.MustFromBytes(token.ID)),
+			IPAddress: token.IPAddress,
+			UserAgent: token.UserAgent,
+			IssuedAt:  token.IssuedAt,
+			ExpiresAt: token.ExpiresAt,
+			RevokedAt: token.RevokedAt,
+		})
+	}
+
+	contents.AuditEvents, err = e.auditLogger.ListAll(userID)
+	if err != nil {
+		return exportContents{}, err
+	}
+	if contents.AuditEvents == nil {
+		contents.AuditEvents = []audit.EventResponse{}
+	}
+
+	if user.ScimTenantID != nil {
+		tenant, err := e.scimTenantRepo.GetByID(ulidutil.MustFromBytes(*user.ScimTenantID))
+		if err != nil {
+			return exportContents{}, err
+		}
+		groups, err := e.groupRepo.ListByMember(userID)
+		if err != nil {
+			return exportContents{}, err
+		}
+
+		identity := exportIdentity{Provider: "scim", Tenant: tenant.Name, ExternalID: user.ExternalID, Groups: []string{}}
+		for _, group := range groups {
+			identity.Groups = append(identity.Groups, group.DisplayName)
+		}
+		contents.Identities = append(contents.Identities, identity)
+	}
+
+	changes, err := e.emailChangeRequestRepo.ListByUserID(userID)
+	if err != nil {
+		return exportContents{}, err
+	}
+	for _, change := range changes {
+		contents.EmailHistory.Changes = append(contents.EmailHistory.Changes, exportEmailChange{
+			PreviousEmail: change.PreviousEmail,
+			NewEmail:      change.NewEmail,
+			ConfirmedAt:   change.ConfirmedAt,
+			CancelledAt:   change.CancelledAt,
+			CreatedAt:     change.CreatedAt,
+		})
+	}
+
+	messages, err := e.emailMessageRepo.ListByUserID(userID)
+	if err != nil {
+		return exportContents{}, err
+	}
+	for _, message := range messages {
+		contents.EmailHistory.Messages = append(contents.EmailHistory.Messages, exportEmailMessage{
+			Template:  message.Template,
+			Recipient: message.Recipient,
+			Status:    message.Status,
+			CreatedAt: message.CreatedAt,
+			UpdatedAt: message.UpdatedAt,
+		})
+	}
+
+	return contents, nil
+}
+
+// zip writes each section of the export to its own file.
+func (c exportContents) zip() ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", c.Profile},
+		{"sessions.json", c.Sessions},
+		{"audit_events.json", c.AuditEvents},
+		{"identities.json", c.Identities},
+		{"email_history.json", c.EmailHistory},
+	}
+	for _, file := range files {
+		encoded, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: c.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
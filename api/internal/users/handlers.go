@@ -2,9 +2,15 @@ package users
 
 import (
 	"auth/internal/apperror"
+	"auth/internal/audit"
 	"auth/internal/auth"
+	"auth/internal/httputil"
 	"auth/internal/jet/postgres/public/model"
+	"auth/internal/passwordpolicy"
+	"auth/internal/repositories"
 	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"database/sql"
 	"time"
 
 	"github.com/oklog/ulid/v2"
@@ -15,12 +21,17 @@ type GetUserParams struct {
 }
 
 type GetUserResponse struct {
-	ID            string    `json:"id"`
-	Email         string    `json:"email"`
-	Username      string    `json:"username"`
-	EmailVerified bool      `json:"email_verified"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	EmailVerified bool   `json:"email_verified"`
+	// A new address waiting to be confirmed, Email stays unchanged until then
+	PendingEmail *string `json:"pending_email"`
+	// Mail to the address has hard bounced, so the user should change it
+	EmailUndeliverable bool      `json:"email_undeliverable"`
+	Locale             string    `json:"locale"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
 func (s *UsersService) GetUser(userID ulid.ULID) (GetUserResponse, error) {
@@ -29,29 +40,51 @@ func (s *UsersService) GetUser(userID ulid.ULID) (GetUserResponse, error) {
 		return GetUserResponse{}, err
 	}
 
+	undeliverable, err := s.emailService.IsUndeliverable(user.Email)
+	if err != nil {
+		return GetUserResponse{}, err
+	}
+
+	var pendingEmail *string
+	pendingChange, err := s.emailChangeRequestRepo.GetPendingByUserID(userID)
+	if err != nil {
+		return GetUserResponse{}, err
+	}
+	if pendingChange != nil {
+		pendingEmail = &pendingChange.NewEmail
+	}
+
 	return GetUserResponse{
-		ID:            ulidutil.ToPrefixed("user", userID),
-		Email:         user.Email,
-		Username:      user.Username,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
-		UpdatedAt:     user.UpdatedAt,
+		ID:                 ulidutil.ToPrefixed("user", userID),
+		Email:              user.Email,
+		Username:           user.Username,
+		EmailVerified:      user.EmailVerified,
+		PendingEmail:       pendingEmail,
+		EmailUndeliverable: undeliverable,
+		Locale:             user.Locale,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
 	}, nil
 }
 
 type UpdateUserParams struct {
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	// Left unchanged when empty
+	Locale string `json:"locale,omitempty"`
 }
 
-func (s *UsersService) UpdateUser(userID ulid.ULID, params UpdateUserParams) error {
-	user := model.Users{
+// UpdateUser saves the profile. A new email address only takes effect once it
+// is confirmed from a link sent to it, and the current address is sent a link
+// to cancel the change in case it wasn't the user asking. Changing the
+// address needs a recent authentication, so that a stolen access token can't
+// be used to take over the account.
+func (s *UsersService) UpdateUser(userID ulid.ULID, params UpdateUserParams, recentlyAuthenticated bool, meta httputil.RequestMeta) error {
+	willConflict, err := s.userRepo.WillConflict(model.Users{
 		ID:       userID.Bytes(),
 		Email:    params.Email,
 		Username: params.Username,
-	}
-
-	willConflict, err := s.userRepo.WillConflict(user)
+	})
 	if err != nil {
 		return err
 	}
@@ -63,28 +96,149 @@ func (s *UsersService) UpdateUser(userID ulid.ULID, params UpdateUserParams) err
 	if err != nil {
 		return err
 	}
-	if existing.Email != params.Email {
-		user.EmailVerified = false
+	emailChanged := existing.Email != params.Email
+	if emailChanged && !recentlyAuthenticated {
+		return apperror.NewReauthenticationRequired()
+	}
 
-		userID := ulidutil.MustFromBytes(user.ID)
-		s.emailVerificationTokenRepo.RevokeByUserID(userID)
+	// The addresses and usernames of recently deleted accounts stay reserved
+	reserved := false
+	if emailChanged {
+		if reserved, err = s.accountTombstoneRepo.Exists(repositories.AccountTombstoneEmail, params.Email); err != nil {
+			return err
+		}
+	}
+	if !reserved && existing.Username != params.Username {
+		if reserved, err = s.accountTombstoneRepo.Exists(repositories.AccountTombstoneUsername, params.Username); err != nil {
+			return err
+		}
+	}
+	if reserved {
+		return apperror.NewConflict("Username or email already in use")
+	}
 
-		token, hashedToken := auth.GenerateResetToken()
-		emailVerificationTokenModel := model.EmailVerificationTokens{
-			ID:        ulid.Make().Bytes(),
-			UserID:    user.ID,
-			TokenHash: hashedToken,
-			ExpiresAt: time.Now().Add(time.Duration(24) * time.Hour),
-			RevokedAt: nil,
-			CreatedAt: time.Now(),
+	user := model.Users{
+		ID:            userID.Bytes(),
+		Email:         existing.Email,
+		Username:      params.Username,
+		EmailVerified: existing.EmailVerified,
+		Locale:        existing.Locale,
+	}
+	if params.Locale != "" {
+		locale, ok := s.emailService.SupportedLocale(params.Locale)
+		if !ok {
+			return apperror.NewBadRequest("Unsupported locale")
 		}
-		s.emailVerificationTokenRepo.Create(emailVerificationTokenModel)
-		urlEncodedToken := auth.URLEncodeToken(token)
+		user.Locale = locale
+	}
+
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.Update(user); err != nil {
+			return err
+		}
+
+		if !emailChanged {
+			return nil
+		}
+
+		emailChangeRequestRepo := repositories.NewEmailChangeRequestRepository(tx)
+		if err := emailChangeRequestRepo.CancelPendingByUserID(userID); err != nil {
+			return err
+		}
+
+		confirmToken, hashedConfirmToken := auth.GenerateResetToken()
+		cancelToken, hashedCancelToken := auth.GenerateResetToken()
+		emailChangeRequestModel := model.EmailChangeRequests{
+			ID:               ulid.Make().Bytes(),
+			UserID:           user.ID,
+			PreviousEmail:    existing.Email,
+			NewEmail:         params.Email,
+			ConfirmTokenHash: hashedConfirmToken,
+			CancelTokenHash:  hashedCancelToken,
+			ExpiresAt:        time.Now().Add(time.Duration(24) * time.Hour),
+			// Outlives the confirmation so a change made by someone else can
+			// still be undone after they confirm it
+			CancelExpiresAt: time.Now().Add(time.Duration(7*24) * time.Hour),
+			CreatedAt:       time.Now(),
+		}
+		if err := emailChangeRequestRepo.Create(emailChangeRequestModel); err != nil {
+			return err
+		}
+
+		if err := s.emailService.SendConfirmEmailChangeEmail(tx, userID, params.Email, user.Username, user.Locale, auth.URLEncodeToken(confirmToken)); err != nil {
+			return err
+		}
+		return s.emailService.SendEmailChangeRequestedEmail(tx, userID, existing.Email, user.Username, user.Locale, params.Email, auth.URLEncodeToken(cancelToken))
+	})
+	if err != nil {
+		return err
+	}
 
-		s.emailService.SendVerifyEmail(params.Email, params.Username, urlEncodedToken)
+	changes := map[string]any{}
+	if existing.Username != params.Username {
+		changes["username"] = map[string]string{"from": existing.Username, "to": params.Username}
+	}
+	if existing.Locale != user.Locale {
+		changes["locale"] = map[string]string{"from": existing.Locale, "to": user.Locale}
+	}
+	if len(changes) > 0 {
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventProfileUpdated,
+			ActorID:  &userID,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: changes,
+		})
+	}
+	if emailChanged {
+		s.auditLogger.Record(audit.Event{
+			Type:     audit.EventEmailChangeRequested,
+			ActorID:  &userID,
+			UserID:   &userID,
+			Request:  meta,
+			Metadata: map[string]any{"email": map[string]string{"from": existing.Email, "to": params.Email}},
+		})
 	}
 
-	return s.userRepo.Update(user)
+	return nil
+}
+
+// SendVerificationEmail sends the user a new link to verify their address,
+// replacing any sent before.
+func (s *UsersService) SendVerificationEmail(userID ulid.ULID, meta httputil.RequestMeta) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return apperror.NewConflict("Email already verified")
+	}
+
+	undeliverable, err := s.emailService.IsUndeliverable(user.Email)
+	if err != nil {
+		return err
+	}
+	if undeliverable {
+		return apperror.NewUnprocessableEntity("Email address is undeliverable, change it first")
+	}
+
+	retryAt, err := s.emailVerifier.Resend(user)
+	if err != nil {
+		return err
+	}
+	if !retryAt.IsZero() {
+		return apperror.NewTooManyRequests("Verification email sent recently, try again later")
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventVerificationEmailSent,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return nil
 }
 
 type UpdatePasswordParams struct {
@@ -92,34 +246,129 @@ type UpdatePasswordParams struct {
 	NewPassword     string `json:"new_password"`
 }
 
-func (s *UsersService) UpdatePassword(userID ulid.ULID, params UpdatePasswordParams) error {
+func (s *UsersService) UpdatePassword(userID ulid.ULID, params UpdatePasswordParams, meta httputil.RequestMeta) ([]apperror.Violation, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !auth.ComparePasswordAndHash(params.CurrentPassword, user.PasswordHash) {
-		return apperror.NewUnauthorized("Unauthorized")
+	if !s.passwordHasher.Compare(params.CurrentPassword, user.PasswordHash) {
+		return nil, apperror.NewUnauthorized("Unauthorized")
 	}
 
-	passwordHash, err := auth.HashPassword(params.NewPassword)
+	warnings, err := s.passwordPolicy.Validate(passwordpolicy.Candidate{
+		Password: params.NewPassword,
+		Username: user.Username,
+		Email:    user.Email,
+		User:     user,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.userRepo.SetPassword(userID, passwordHash)
+	passwordHash, err := s.passwordHasher.Hash(params.NewPassword)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.refreshTokenRepo.RevokeByUserID(userID)
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.SetPassword(userID, passwordHash); err != nil {
+			return err
+		}
+
+		if err := s.passwordPolicy.Remember(tx, userID, passwordHash); err != nil {
+			return err
+		}
+
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		return refreshTokenRepo.RevokeByUserID(userID)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	s.auditLogger.Record(audit.Event{
+		Type:    audit.EventPasswordChanged,
+		ActorID: &userID,
+		UserID:  &userID,
+		Request: meta,
+	})
+
+	return warnings, nil
+}
+
+type DeleteUserResponse struct {
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// DeleteUser schedules the account to be purged once the grace period ends
+// and signs the user out everywhere. They are emailed a link to cancel the
+// deletion until then.
+func (s *UsersService) DeleteUser(userID ulid.ULID, meta httputil.RequestMeta) (DeleteUserResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return DeleteUserResponse{}, err
+	}
+	if user.DeleteAfter != nil {
+		return DeleteUserResponse{}, apperror.NewConflict("Account deletion already scheduled")
+	}
+
+	deleteAfter := time.Now().Add(s.deletionPolicy.GracePeriod)
+	token, hashedToken := auth.GenerateResetToken()
+	err = repositories.WithTx(s.db, func(tx *sql.Tx) error {
+		userRepo := repositories.NewUserRepository(tx)
+		if err := userRepo.ScheduleDeletion(userID, deleteAfter); err != nil {
+			return err
+		}
+
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(tx)
+		if err := refreshTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+
+		accountDeletionTokenRepo := repositories.NewAccountDeletionTokenRepository(tx)
+		if err := accountDeletionTokenRepo.RevokeByUserID(userID); err != nil {
+			return err
+		}
+		accountDeletionTokenModel := model.AccountDeletionTokens{
+			ID:        ulid.Make().Bytes(),
+			UserID:    user.ID,
+			TokenHash: hashedToken,
+			ExpiresAt: deleteAfter,
+			RevokedAt: nil,
+			CreatedAt: time.Now(),
+		}
+		if err := accountDeletionTokenRepo.Create(accountDeletionTokenModel); err != nil {
+			return err
+		}
+
+		if err := s.emailService.SendAccountDeletionScheduledEmail(tx, userID, user.Email, user.Username, user.Locale, auth.URLEncodeToken(token), deleteAfter); err != nil {
+			return err
+		}
+
+		return s.webhookDispatcher.Emit(tx, webhooks.Event{
+			Type:   webhooks.EventUserDeletionScheduled,
+			UserID: userID,
+			Data:   map[string]any{"email": user.Email, "delete_after": deleteAfter},
+		})
+	})
+	if err != nil {
+		return DeleteUserResponse{}, err
+	}
+
+	s.auditLogger.Record(audit.Event{
+		Type:     audit.EventDeletionScheduled,
+		ActorID:  &userID,
+		UserID:   &userID,
+		Request:  meta,
+		Metadata: map[string]any{"delete_after": deleteAfter},
+	})
+
+	return DeleteUserResponse{DeleteAfter: deleteAfter}, nil
 }
 
-func (s *UsersService) DeleteUser(userID ulid.ULID) error {
-	return s.userRepo.Delete(userID)
+func (s *UsersService) ListSecurityEvents(userID ulid.ULID, filter repositories.AuditEventFilter) (audit.ListResponse, error) {
+	filter.UserID = &userID
+	return s.auditLogger.List(filter)
 }
@@ -0,0 +1,106 @@
+package users
+
+import (
+	"auth/internal/audit"
+	"auth/internal/repositories"
+	"auth/internal/ulidutil"
+	"auth/internal/webhooks"
+	"database/sql"
+	"time"
+)
+
+// DeletionPolicy decides how long a deleted account can still be restored,
+// and how long its email and username stay reserved once it is purged.
+type DeletionPolicy struct {
+	GracePeriod     time.Duration
+	TombstonePeriod time.Duration
+}
+
+const purgeBatchSize = 100
+
+// Purger permanently deletes accounts whose deletion grace period has ended.
+type Purger struct {
+	db                *sql.DB
+	policy            DeletionPolicy
+	auditLogger       *audit.Logger
+	webhookDispatcher *webhooks.Dispatcher
+
+	userRepo             repositories.UserRepository
+	accountTombstoneRepo repositories.AccountTombstoneRepository
+}
+
+func NewPurger(db *sql.DB, policy DeletionPolicy, auditLogger *audit.Logger, webhookDispatcher *webhooks.Dispatcher) *Purger {
+	return &Purger{
+		db:                   db,
+		policy:               policy,
+		auditLogger:          auditLogger,
+		webhookDispatcher:    webhookDispatcher,
+		userRepo:             repositories.NewUserRepository(db),
+		accountTombstoneRepo: repositories.NewAccountTombstoneRepository(db),
+	}
+}
+
+// DeleteExpiredTombstones frees the emails and usernames of accounts purged
+// longer ago than the tombstone period and returns how many it freed.
+func (p *Purger) DeleteExpiredTombstones() (int64, error) {
+	return p.accountTombstoneRepo.DeleteExpired()
+}
+
+// PurgeDue deletes every account whose grace period has ended, reserving its
+// email and username, and returns how many it deleted.
+func (p *Purger) PurgeDue() (int64, error) {
+	var purged int64
+	for {
+		users, err := p.userRepo.ListDueForDeletion(purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, user := range users {
+			userID := ulidutil.MustFromBytes(user.ID)
+			tombstoneExpiresAt := time.Now().Add(p.policy.TombstonePeriod)
+
+			deleted := false
+			err := repositories.WithTx(p.db, func(tx *sql.Tx) error {
+				userRepo := repositories.NewUserRepository(tx)
+				var err error
+				deleted, err = userRepo.DeleteIfDue(userID)
+				// Cancelled since it was listed
+				if err != nil || !deleted {
+					return err
+				}
+
+				accountTombstoneRepo := repositories.NewAccountTombstoneRepository(tx)
+				if err := accountTombstoneRepo.Create(repositories.AccountTombstoneEmail, user.Email, tombstoneExpiresAt); err != nil {
+					return err
+				}
+				if err := accountTombstoneRepo.Create(repositories.AccountTombstoneUsername, user.Username, tombstoneExpiresAt); err != nil {
+					return err
+				}
+
+				return p.webhookDispatcher.Emit(tx, webhooks.Event{
+					Type:   webhooks.EventUserDeleted,
+					UserID: userID,
+					Data:   map[string]any{"email": user.Email},
+				})
+			})
+			if err != nil {
+				return purged, err
+			}
+			if !deleted {
+				continue
+			}
+			purged++
+
+			p.auditLogger.Record(audit.Event{
+				Type:     audit.EventUserDeleted,
+				UserID:   &userID,
+				Metadata: map[string]any{"reason": "grace_period_ended"},
+			})
+		}
+
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
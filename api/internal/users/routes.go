@@ -1,88 +1,189 @@
 package users
 
 import (
+	"auth/internal/audit"
+	"auth/internal/auth"
 	"auth/internal/httputil"
 	"auth/internal/middleware"
+	"auth/internal/passwordpolicy"
 	"crypto/ed25519"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/oklog/ulid/v2"
 )
 
 func Router(s *UsersService) http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Auth(s.jwtAccessKey.Public().(ed25519.PublicKey), s.issuer))
-
-	r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context().Value(middleware.AuthContextKey).(*jwt.RegisteredClaims)
-		userID, err := ulid.Parse(ctx.Subject)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-		}
-
-		response, err := s.GetUser(userID)
-		if err != nil {
-			httputil.HandleError(w, err)
-			return
-		}
-
-		httputil.JSONResponse(w, http.StatusOK, response)
-	})
+	publicKey := s.jwtAccessKey.Public().(ed25519.PublicKey)
 
-	r.Put("/me", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context().Value(middleware.AuthContextKey).(*jwt.RegisteredClaims)
-		userID, err := ulid.Parse(ctx.Subject)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-		}
-
-		var body UpdateUserParams
-		if err := httputil.ParseBody(w, r, &body); err != nil {
-			return
-		}
-
-		err = s.UpdateUser(userID, body)
-		if err != nil {
-			httputil.HandleError(w, err)
-			return
-		}
-		w.WriteHeader(http.StatusNoContent)
-	})
+	// Reachable with a token restricted to verifying the email address, so
+	// that the user can correct it and ask for a new link
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AuthRestricted(publicKey, s.issuer))
+
+		r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			response, err := s.GetUser(userID)
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
+
+		r.Put("/me", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			var body UpdateUserParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
 
-	r.Post("/me/password", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context().Value(middleware.AuthContextKey).(*jwt.RegisteredClaims)
-		userID, err := ulid.Parse(ctx.Subject)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-		}
-
-		var body UpdatePasswordParams
-		if err := httputil.ParseBody(w, r, &body); err != nil {
-			return
-		}
-
-		err = s.UpdatePassword(userID, body)
-		if err != nil {
-			httputil.HandleError(w, err)
-			return
-		}
-		w.WriteHeader(http.StatusNoContent)
+			err = s.UpdateUser(userID, body, ctx.AuthenticatedWithin(s.reauthMaxAge), httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.Post("/me/verify-email", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			if err := s.SendVerificationEmail(userID, httputil.GetRequestMeta(r)); err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
 	})
 
-	r.Delete("/me", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context().Value(middleware.AuthContextKey).(*jwt.RegisteredClaims)
-		userID, err := ulid.Parse(ctx.Subject)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-		}
-
-		err = s.DeleteUser(userID)
-		if err != nil {
-			httputil.HandleError(w, err)
-		}
-		w.WriteHeader(http.StatusNoContent)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth(publicKey, s.issuer))
+
+		r.Post("/me/password", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			var body UpdatePasswordParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			warnings, err := s.UpdatePassword(userID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+			if len(warnings) > 0 {
+				httputil.JSONResponse(w, http.StatusOK, passwordpolicy.WarningsResponse{Warnings: warnings})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.With(middleware.RequireRecentAuth(s.reauthMaxAge)).Delete("/me", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			response, err := s.DeleteUser(userID, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusAccepted, response)
+		})
+
+		r.Post("/me/export", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			var body RequestExportParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			response, err := s.RequestExport(userID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusAccepted, response)
+		})
+
+		r.Post("/me/export/download", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			var body DownloadExportParams
+			if err := httputil.ParseBody(w, r, &body); err != nil {
+				return
+			}
+
+			archive, err := s.DownloadExport(userID, body, httputil.GetRequestMeta(r))
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			w.Header().Set("Content-Type", archive.ContentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="`+archive.Filename+`"`)
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			w.Write(archive.Data)
+		})
+
+		r.Get("/me/security-events", func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(middleware.AuthContextKey).(*auth.AccessClaims)
+			userID, err := ulid.Parse(ctx.Subject)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}
+
+			filter, err := audit.ParseFilter(r.URL.Query())
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			response, err := s.ListSecurityEvents(userID, filter)
+			if err != nil {
+				httputil.HandleError(w, err)
+				return
+			}
+
+			httputil.JSONResponse(w, http.StatusOK, response)
+		})
 	})
 
 	return r
@@ -1,33 +1,56 @@
 package users
 
 import (
+	"auth/internal/audit"
+	"auth/internal/auth"
 	"auth/internal/emails"
+	"auth/internal/passwordhash"
+	"auth/internal/passwordpolicy"
 	"auth/internal/repositories"
+	"auth/internal/webhooks"
 	"crypto/ed25519"
 	"database/sql"
+	"time"
 )
 
 type UsersService struct {
-	db            *sql.DB
-	jwtAccessKey  ed25519.PrivateKey
-	jwtRefreshKey ed25519.PrivateKey
-	issuer        string
-	emailService  *emails.EmailService
+	db                *sql.DB
+	jwtAccessKey      ed25519.PrivateKey
+	jwtRefreshKey     ed25519.PrivateKey
+	issuer            string
+	reauthMaxAge      time.Duration
+	emailService      *emails.EmailService
+	emailVerifier     *auth.EmailVerifier
+	auditLogger       *audit.Logger
+	webhookDispatcher *webhooks.Dispatcher
+	passwordHasher    *passwordhash.Hasher
+	passwordPolicy    *passwordpolicy.Policy
+	deletionPolicy    DeletionPolicy
+	exporter          *Exporter
 
-	userRepo                   repositories.UserRepository
-	refreshTokenRepo           repositories.RefreshTokenRepository
-	emailVerificationTokenRepo repositories.EmailVerificationTokenRepository
+	userRepo               repositories.UserRepository
+	emailChangeRequestRepo repositories.EmailChangeRequestRepository
+	accountTombstoneRepo   repositories.AccountTombstoneRepository
 }
 
-func NewUsersService(db *sql.DB, jwtAccessKey ed25519.PrivateKey, jwtRefreshKey ed25519.PrivateKey, issuer string, emailService *emails.EmailService) (*UsersService, error) {
+func NewUsersService(db *sql.DB, jwtAccessKey ed25519.PrivateKey, jwtRefreshKey ed25519.PrivateKey, issuer string, reauthMaxAge time.Duration, emailService *emails.EmailService, emailVerifier *auth.EmailVerifier, auditLogger *audit.Logger, webhookDispatcher *webhooks.Dispatcher, passwordHasher *passwordhash.Hasher, passwordPolicy *passwordpolicy.Policy, deletionPolicy DeletionPolicy, exporter *Exporter) (*UsersService, error) {
 	return &UsersService{
-		db:                         db,
-		jwtAccessKey:               jwtAccessKey,
-		jwtRefreshKey:              jwtRefreshKey,
-		issuer:                     issuer,
-		emailService:               emailService,
-		userRepo:                   repositories.NewUserRepository(db),
-		refreshTokenRepo:           repositories.NewRefreshTokenRepository(db),
-		emailVerificationTokenRepo: repositories.NewEmailVerificationTokenRepository(db),
+		db:                db,
+		jwtAccessKey:      jwtAccessKey,
+		jwtRefreshKey:     jwtRefreshKey,
+		issuer:            issuer,
+		reauthMaxAge:      reauthMaxAge,
+		emailService:      emailService,
+		emailVerifier:     emailVerifier,
+		auditLogger:       auditLogger,
+		webhookDispatcher: webhookDispatcher,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		deletionPolicy:    deletionPolicy,
+		exporter:          exporter,
+		userRepo:          repositories.NewUserRepository(db),
+
+		emailChangeRequestRepo: repositories.NewEmailChangeRequestRepository(db),
+		accountTombstoneRepo:   repositories.NewAccountTombstoneRepository(db),
 	}, nil
 }
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"auth/internal/jet/postgres/public/model"
+	"auth/internal/outbox"
+	"auth/internal/repositories"
+	"auth/internal/retry"
+	"auth/internal/ulidutil"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Only the start of a response is kept for the delivery log
+const maxResponseExcerpt = 1000
+
+type Config struct {
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BatchSize    int
+}
+
+// OutboxKind identifies events waiting in the outbox to be fanned out to
+// subscriptions.
+const OutboxKind = "webhook_event"
+
+// Event is set by the caller except for ID and CreatedAt, which Emit fills in.
+type Event struct {
+	ID        ulid.ULID      `json:"id"`
+	Type      string         `json:"type"`
+	UserID    ulid.ULID      `json:"user_id"`
+	Data      map[string]any `json:"data"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Payload is the JSON body posted to subscribers. Retries and redeliveries
+// reuse the event ID so receivers can deduplicate.
+type Payload struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      map[string]any `json:"data"`
+}
+
+// Dispatcher queues events for every interested subscription and delivers
+// them in the background, retrying failures with exponential backoff. The
+// queue lives in the database, so pending deliveries survive restarts and
+// several instances can share the work.
+type Dispatcher struct {
+	client *http.Client
+	outbox *outbox.Outbox
+	worker *retry.Worker[model.WebhookDeliveries]
+
+	subscriptionRepo repositories.WebhookSubscriptionRepository
+	deliveryRepo     repositories.WebhookDeliveryRepository
+}
+
+func NewDispatcher(db *sql.DB, config Config, outbox *outbox.Outbox) *Dispatcher {
+	d := &Dispatcher{
+		client:           &http.Client{Timeout: config.Timeout},
+		outbox:           outbox,
+		subscriptionRepo: repositories.NewWebhookSubscriptionRepository(db),
+		deliveryRepo:     repositories.NewWebhookDeliveryRepository(db),
+	}
+	d.worker = retry.NewWorker(retry.Config{
+		PollInterval: config.PollInterval,
+		MaxAttempts:  config.MaxAttempts,
+		BatchSize:    config.BatchSize,
+		// Outlast the request so a slow receiver doesn't get a second
+		// attempt while the first is still in flight
+		Lease: config.Timeout + time.Minute,
+	}, d.deliveryRepo.ClaimDue, d.deliver)
+	return d
+}
+
+// Emit queues the event through db, which should be the transaction making
+// the change it describes, so that subscribers only hear about committed
+// changes.
+func (d *Dispatcher) Emit(db repositories.DB, event Event) error {
+	event.ID = ulid.Make()
+	event.CreatedAt = time.Now()
+	return d.outbox.Enqueue(db, OutboxKind, event)
+}
+
+// Publish queues a delivery of an event from the outbox to each subscription
+// that wants it. It is the outbox handler for OutboxKind.
+func (d *Dispatcher) Publish(_ ulid.ULID, payload []byte) error {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to decode webhook event: %w", err)
+	}
+
+	subscriptions, err := d.subscriptionRepo.ListActiveForEvent(event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	data := map[string]any{"user_id": ulidutil.ToPrefixed("user", event.UserID)}
+	for key, value := range event.Data {
+		data[key] = value
+	}
+
+	body, err := json.Marshal(Payload{
+		ID:        ulidutil.ToPrefixed("event", event.ID),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDeliveries, len(subscriptions))
+	for i, subscription := range subscriptions {
+		deliveries[i] = model.WebhookDeliveries{
+			ID:             ulid.Make().Bytes(),
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID.Bytes(),
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         repositories.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+	}
+	return d.deliveryRepo.Create(deliveries...)
+}
+
+// Run delivers due webhooks until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.worker.Run(ctx)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery model.WebhookDeliveries) {
+	deliveryID := ulidutil.MustFromBytes(delivery.ID)
+
+	subscription, err := d.subscriptionRepo.GetByID(ulidutil.MustFromBytes(delivery.SubscriptionID))
+	if err != nil {
+		// Leave it to be retried once the lease runs out
+		return
+	}
+	if subscription == nil || !subscription.Active {
+		message := "Subscription is disabled"
+		d.deliveryRepo.RecordAttempt(deliveryID, repositories.WebhookDeliveryFailed, nil, &message, time.Now())
+		return
+	}
+
+	responseStatus, err := d.send(ctx, *subscription, delivery)
+	if err == nil {
+		d.deliveryRepo.RecordAttempt(deliveryID, repositories.WebhookDeliveryDelivered, responseStatus, nil, time.Now())
+		return
+	}
+
+	message := retry.ErrorMessage(err)
+	nextAttemptAt, ok := d.worker.NextAttempt(delivery.Attempts)
+	if !ok {
+		log.Printf("[WARN] Webhook delivery %s failed after %d attempts: %s", ulidutil.ToPrefixed("delivery", deliveryID), delivery.Attempts, message)
+		d.deliveryRepo.RecordAttempt(deliveryID, repositories.WebhookDeliveryFailed, responseStatus, &message, time.Now())
+		return
+	}
+
+	d.deliveryRepo.RecordAttempt(deliveryID, repositories.WebhookDeliveryPending, responseStatus, &message, nextAttemptAt)
+}
+
+// send posts the payload, returning the response status if one was received
+// and an error unless it was a 2xx.
+func (d *Dispatcher) send(ctx context.Context, subscription model.WebhookSubscriptions, delivery model.WebhookDeliveries) (*int32, error) {
+	body := []byte(delivery.Payload)
+	messageID := ulidutil.ToPrefixed("event", ulidutil.MustFromBytes(delivery.EventID))
+	timestamp := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, messageID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp.Unix()))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, messageID, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	status := int32(resp.StatusCode)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return &status, nil
+	}
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+	if len(excerpt) == 0 {
+		return &status, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return &status, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, excerpt)
+}
@@ -0,0 +1,33 @@
+package webhooks
+
+// Event types that subscriptions can ask for. They are part of the public
+// webhook contract, so existing names must not change.
+const (
+	EventUserRegistered    = "user.registered"
+	EventUserEmailVerified = "user.email_verified"
+	EventUserEmailChanged  = "user.email_changed"
+	EventUserPasswordReset = "user.password_reset"
+	EventUserDeleted       = "user.deleted"
+
+	EventUserDeletionScheduled = "user.deletion_scheduled"
+	EventUserDeletionCancelled = "user.deletion_cancelled"
+)
+
+var EventTypes = []string{
+	EventUserRegistered,
+	EventUserEmailVerified,
+	EventUserEmailChanged,
+	EventUserPasswordReset,
+	EventUserDeleted,
+	EventUserDeletionScheduled,
+	EventUserDeletionCancelled,
+}
+
+func IsEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
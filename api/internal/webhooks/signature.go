@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signatures follow the Standard Webhooks scheme so that receivers can verify
+// them with an off the shelf library.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+
+	secretPrefix = "whsec_"
+)
+
+func GenerateSecret() string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return secretPrefix + base64.StdEncoding.EncodeToString(key)
+}
+
+// Sign returns the signature header value for a payload. The signed content
+// is the message id, the unix timestamp and the body joined by dots.
+func Sign(secret string, messageID string, timestamp time.Time, body []byte) string {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, secretPrefix))
+	if err != nil {
+		key = []byte(secret)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(messageID))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header produced by Sign, as sent by providers that
+// follow the same scheme. The header may hold several space separated
+// signatures, for example while a secret is being rotated, and any valid one
+// is accepted. Timestamps further than tolerance from now are rejected to
+// limit replays.
+func Verify(secret string, messageID string, timestamp string, body []byte, signatureHeader string, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	signedAt := time.Unix(unix, 0)
+	if math.Abs(float64(time.Since(signedAt))) > float64(tolerance) {
+		return errors.New("webhook timestamp outside of tolerance")
+	}
+
+	expected := Sign(secret, messageID, signedAt, body)
+	for _, signature := range strings.Fields(signatureHeader) {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("no matching webhook signature")
+}
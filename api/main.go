@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/x509"
 	"database/sql"
@@ -8,11 +9,24 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
+	"auth/internal/admin"
+	"auth/internal/audit"
 	"auth/internal/auth"
+	"auth/internal/breach"
+	"auth/internal/devmail"
 	"auth/internal/emails"
+	"auth/internal/httputil"
+	"auth/internal/jobs"
+	"auth/internal/outbox"
+	"auth/internal/passwordhash"
+	"auth/internal/passwordpolicy"
+	"auth/internal/ratelimit"
+	"auth/internal/scim"
 	"auth/internal/users"
+	"auth/internal/webhooks"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/go-chi/chi/v5"
@@ -26,11 +40,98 @@ type Config struct {
 	JWTAccessKeyPEM  string `env:"JWT_ACCESS_KEY_FILE,file,required"`
 	JWTRefreshKeyPEM string `env:"JWT_REFRESH_KEY_FILE,file,required"`
 	IssuerUrl        string `env:"ISSUER_URL,required"`
-	ResendAPIKey     string `env:"RESEND_API_KEY,required"`
 	FromEmail        string `env:"FROM_EMAIL,required"`
 	FrontendURL      string `env:"FRONTEND_URL,required"`
 	ServiceName      string `env:"SERVICE_NAME,required"`
 	SupportEmail     string `env:"SUPPORT_EMAIL,required"`
+
+	// Directory of <template>.html and <template>.txt files replacing the
+	// built in email templates of the same name
+	EmailTemplatesDir     string `env:"EMAIL_TEMPLATES_DIR"`
+	EmailLogoURL          string `env:"EMAIL_LOGO_URL"`
+	EmailPrimaryColor     string `env:"EMAIL_PRIMARY_COLOR" envDefault:"#000000"`
+	EmailPrimaryTextColor string `env:"EMAIL_PRIMARY_TEXT_COLOR" envDefault:"#ffffff"`
+	EmailFooterAddress    string `env:"EMAIL_FOOTER_ADDRESS"`
+
+	// One of resend, smtp, file or log
+	EmailTransport string `env:"EMAIL_TRANSPORT" envDefault:"resend"`
+	ResendAPIKey   string `env:"RESEND_API_KEY"`
+	// Enables the delivery status webhook at /emails/webhooks/resend
+	ResendWebhookSecret string `env:"RESEND_WEBHOOK_SECRET"`
+	SMTPHost            string `env:"SMTP_HOST"`
+	SMTPPort            int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername        string `env:"SMTP_USERNAME"`
+	SMTPPassword        string `env:"SMTP_PASSWORD"`
+	SMTPTLS             string `env:"SMTP_TLS" envDefault:"starttls"`
+	EmailFileDir        string `env:"EMAIL_FILE_DIR" envDefault:"tmp/mail"`
+	// Directory of <locale>.json catalogs adding locales or overriding
+	// strings of the built in ones
+	EmailLocalesDir string `env:"EMAIL_LOCALES_DIR"`
+	// Serves the emails captured by the file transport at /_dev/mail,
+	// tokens included, to anyone who can reach the server
+	DevMailInbox bool `env:"DEV_MAIL_INBOX" envDefault:"false"`
+
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For
+	// entries are believed. Without any, clients are identified by the
+	// address they connect from.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
+	RateLimitStore      string        `env:"RATE_LIMIT_STORE" envDefault:"memory"`
+	RateLimitWindow     time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"15m"`
+	RateLimitIPLimit    int           `env:"RATE_LIMIT_IP_LIMIT" envDefault:"50"`
+	RateLimitEmailLimit int           `env:"RATE_LIMIT_EMAIL_LIMIT" envDefault:"10"`
+	RateLimitPairLimit  int           `env:"RATE_LIMIT_PAIR_LIMIT" envDefault:"5"`
+	// How often counters whose window has ended are deleted from the postgres store
+	RateLimitCleanupInterval time.Duration `env:"RATE_LIMIT_CLEANUP_INTERVAL" envDefault:"1h"`
+
+	LockoutBackoffAfter int           `env:"LOCKOUT_BACKOFF_AFTER" envDefault:"3"`
+	LockoutBackoffBase  time.Duration `env:"LOCKOUT_BACKOFF_BASE" envDefault:"1s"`
+	LockoutThreshold    int           `env:"LOCKOUT_THRESHOLD" envDefault:"10"`
+	LockoutDuration     time.Duration `env:"LOCKOUT_DURATION" envDefault:"30m"`
+
+	ReauthMaxAge time.Duration `env:"REAUTH_MAX_AGE" envDefault:"5m"`
+
+	AccountDeletionGracePeriod time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+	AccountTombstonePeriod     time.Duration `env:"ACCOUNT_TOMBSTONE_PERIOD" envDefault:"2160h"`
+	AccountPurgeInterval       time.Duration `env:"ACCOUNT_PURGE_INTERVAL" envDefault:"1h"`
+
+	DataExportLinkTTL time.Duration `env:"DATA_EXPORT_LINK_TTL" envDefault:"24h"`
+
+	EnumerationSafe            bool          `env:"ENUMERATION_SAFE" envDefault:"false"`
+	EnumerationMinResponseTime time.Duration `env:"ENUMERATION_MIN_RESPONSE_TIME" envDefault:"500ms"`
+
+	EmailVerificationPolicy    string `env:"EMAIL_VERIFICATION_POLICY" envDefault:"optional"`
+	EmailVerificationGraceDays int    `env:"EMAIL_VERIFICATION_GRACE_DAYS" envDefault:"7"`
+
+	VerificationResendInterval   time.Duration `env:"VERIFICATION_RESEND_INTERVAL" envDefault:"1m"`
+	VerificationResendDailyLimit int           `env:"VERIFICATION_RESEND_DAILY_LIMIT" envDefault:"5"`
+
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"1"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"0"`
+
+	PasswordMinLength   int `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordMaxLength   int `env:"PASSWORD_MAX_LENGTH" envDefault:"128"`
+	PasswordMinScore    int `env:"PASSWORD_MIN_SCORE" envDefault:"2"`
+	PasswordHistorySize int `env:"PASSWORD_HISTORY_SIZE" envDefault:"5"`
+
+	BreachCorpusPath     string `env:"BREACH_CORPUS_PATH"`
+	BreachRejectMinCount int    `env:"BREACH_REJECT_MIN_COUNT" envDefault:"1"`
+
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"2s"`
+	OutboxMaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"10"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" envDefault:"20"`
+
+	WebhookPollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"5s"`
+	WebhookTimeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	WebhookMaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	WebhookBatchSize    int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"20"`
+
+	JobPollInterval                 time.Duration `env:"JOB_POLL_INTERVAL" envDefault:"1m"`
+	TokenCleanupInterval            time.Duration `env:"TOKEN_CLEANUP_INTERVAL" envDefault:"1h"`
+	RefreshTokenRetention           time.Duration `env:"REFRESH_TOKEN_RETENTION" envDefault:"168h"`
+	PasswordResetTokenRetention     time.Duration `env:"PASSWORD_RESET_TOKEN_RETENTION" envDefault:"168h"`
+	EmailVerificationTokenRetention time.Duration `env:"EMAIL_VERIFICATION_TOKEN_RETENTION" envDefault:"168h"`
 }
 
 func parseEd25519PrivateKey(pemContent string) (ed25519.PrivateKey, error) {
@@ -53,6 +154,11 @@ func parseEd25519PrivateKey(pemContent string) (ed25519.PrivateKey, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	var cfg Config
 	err := env.Parse(&cfg)
 	if err != nil {
@@ -76,32 +182,218 @@ func main() {
 		log.Fatalf("failed to parse refresh key: %v", err)
 	}
 
-	// Setup resend
-	emailService, err := emails.NewEmailService(cfg.ResendAPIKey, cfg.FromEmail, cfg.FrontendURL, cfg.ServiceName, cfg.SupportEmail)
+	// Emails and webhook events are written to the outbox alongside the
+	// change that caused them and delivered from it in the background
+	outboxDispatcher := outbox.NewOutbox(db, outbox.Config{
+		PollInterval: cfg.OutboxPollInterval,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		BatchSize:    cfg.OutboxBatchSize,
+	})
+
+	// Setup the email transport
+	var emailSender emails.EmailSender
+	switch cfg.EmailTransport {
+	case "resend":
+		if cfg.ResendAPIKey == "" {
+			log.Fatal("RESEND_API_KEY is required when EMAIL_TRANSPORT is resend")
+		}
+		emailSender = emails.NewResendSender(cfg.ResendAPIKey)
+	case "smtp":
+		emailSender, err = emails.NewSMTPSender(emails.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+		})
+	case "file":
+		emailSender, err = emails.NewFileSender(cfg.EmailFileDir)
+	case "log":
+		emailSender = emails.NewLogSender()
+	default:
+		log.Fatalf("unknown email transport %q, expected resend, smtp, file or log", cfg.EmailTransport)
+	}
+	if err != nil {
+		log.Fatalf("failed to setup email transport: %v", err)
+	}
+
+	emailTemplates, err := emails.LoadTemplates(cfg.EmailTemplatesDir)
+	if err != nil {
+		log.Fatalf("failed to load email templates: %v", err)
+	}
+	emailLocales, err := emails.LoadLocales(cfg.EmailLocalesDir)
+	if err != nil {
+		log.Fatalf("failed to load email locales: %v", err)
+	}
+
+	emailService, err := emails.NewEmailService(db, emailSender, emailTemplates, emailLocales, cfg.FromEmail, cfg.FrontendURL, emails.Branding{
+		ServiceName:      cfg.ServiceName,
+		SupportEmail:     cfg.SupportEmail,
+		LogoURL:          cfg.EmailLogoURL,
+		PrimaryColor:     cfg.EmailPrimaryColor,
+		PrimaryTextColor: cfg.EmailPrimaryTextColor,
+		FooterAddress:    cfg.EmailFooterAddress,
+	}, outboxDispatcher)
+	if err != nil {
+		log.Fatalf("failed to setup email service: %v", err)
+	}
+	outboxDispatcher.Handle(emails.OutboxKind, emailService.Deliver)
+
+	trustedProxies, err := httputil.ParseTrustedProxies(cfg.TrustedProxies)
 	if err != nil {
-		log.Fatalf("Failed to setup resend: %v", err)
+		log.Fatalf("failed to parse trusted proxies: %v", err)
 	}
 
 	// Setup chi router
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(httputil.RealIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
-	authService, err := auth.NewAuthService(db, accessKey, refreshKey, cfg.IssuerUrl, emailService)
+	auditLogger := audit.NewLogger(db)
+
+	// Deliver queued webhooks in the background
+	webhookDispatcher := webhooks.NewDispatcher(db, webhooks.Config{
+		PollInterval: cfg.WebhookPollInterval,
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BatchSize:    cfg.WebhookBatchSize,
+	}, outboxDispatcher)
+	outboxDispatcher.Handle(webhooks.OutboxKind, webhookDispatcher.Publish)
+	go webhookDispatcher.Run(context.Background())
+
+	// Personal data exports are assembled from the outbox too
+	exporter := users.NewExporter(db, cfg.DataExportLinkTTL, outboxDispatcher, emailService, auditLogger)
+	outboxDispatcher.Handle(users.ExportOutboxKind, exporter.Build)
+
+	// Maintenance jobs run on whichever replica holds the scheduler lock
+	if cfg.EmailVerificationTokenRetention < 24*time.Hour {
+		// The resend limit counts the links sent in the last day
+		log.Fatalf("email verification token retention %s is too short, expected at least 24h", cfg.EmailVerificationTokenRetention)
+	}
+	scheduler := jobs.NewScheduler(db, cfg.JobPollInterval)
+	scheduler.RegisterTokenCleanup(cfg.TokenCleanupInterval, jobs.TokenRetention{
+		RefreshTokens:           cfg.RefreshTokenRetention,
+		PasswordResetTokens:     cfg.PasswordResetTokenRetention,
+		EmailVerificationTokens: cfg.EmailVerificationTokenRetention,
+	})
+	scheduler.Register("discard-expired-data-exports", cfg.TokenCleanupInterval, exporter.DiscardExpired)
+
+	go outboxDispatcher.Run(context.Background())
+
+	// Setup rate limiting for the unauthenticated auth endpoints
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgresStore(db)
+		scheduler.RegisterRateLimitCleanup(cfg.RateLimitCleanupInterval)
+	default:
+		log.Fatalf("unknown rate limit store %q, expected memory or postgres", cfg.RateLimitStore)
+	}
+	rateLimiter := ratelimit.NewLimiter(rateLimitStore, ratelimit.Config{
+		Window:     cfg.RateLimitWindow,
+		IPLimit:    cfg.RateLimitIPLimit,
+		EmailLimit: cfg.RateLimitEmailLimit,
+		PairLimit:  cfg.RateLimitPairLimit,
+	})
+
+	lockoutPolicy := auth.LockoutPolicy{
+		BackoffAfter: cfg.LockoutBackoffAfter,
+		BackoffBase:  cfg.LockoutBackoffBase,
+		Threshold:    cfg.LockoutThreshold,
+		Duration:     cfg.LockoutDuration,
+	}
+
+	enumerationPolicy := auth.EnumerationPolicy{
+		Enabled:         cfg.EnumerationSafe,
+		MinResponseTime: cfg.EnumerationMinResponseTime,
+	}
+
+	passwordHasher := passwordhash.NewHasher(passwordhash.Config{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	})
+
+	// Breach screening is skipped unless a corpus is configured
+	var breachChecker breach.Checker
+	if cfg.BreachCorpusPath != "" {
+		breachChecker, err = breach.Open(cfg.BreachCorpusPath)
+		if err != nil {
+			log.Fatalf("failed to load breach corpus: %v", err)
+		}
+	}
+
+	passwordPolicy := passwordpolicy.NewPolicy(db, passwordpolicy.Config{
+		MinLength:            cfg.PasswordMinLength,
+		MaxLength:            cfg.PasswordMaxLength,
+		MinScore:             cfg.PasswordMinScore,
+		HistorySize:          cfg.PasswordHistorySize,
+		BreachRejectMinCount: cfg.BreachRejectMinCount,
+	}, passwordHasher.Compare, breachChecker)
+
+	verificationPolicy := auth.VerificationPolicy(cfg.EmailVerificationPolicy)
+	switch verificationPolicy {
+	case auth.VerificationOptional, auth.VerificationRequired, auth.VerificationRestricted, auth.VerificationGracePeriod:
+	default:
+		log.Fatalf("unknown email verification policy %q, expected optional, required, restricted or grace", cfg.EmailVerificationPolicy)
+	}
+	emailVerifier := auth.NewEmailVerifier(db, auth.VerificationConfig{
+		Policy:         verificationPolicy,
+		GracePeriod:    time.Duration(cfg.EmailVerificationGraceDays) * 24 * time.Hour,
+		ResendInterval: cfg.VerificationResendInterval,
+		DailyLimit:     cfg.VerificationResendDailyLimit,
+	}, emailService)
+
+	authService, err := auth.NewAuthService(db, accessKey, refreshKey, cfg.IssuerUrl, emailService, emailVerifier, auditLogger, webhookDispatcher, rateLimiter, lockoutPolicy, enumerationPolicy, cfg.ReauthMaxAge, passwordHasher, passwordPolicy)
 	if err != nil {
 		log.Fatalf("failed to create auth service: %v", err)
 	}
 	r.Mount("/auth", auth.Router(authService))
 
-	usersService, err := users.NewUsersService(db, accessKey, refreshKey, cfg.IssuerUrl, emailService)
+	deletionPolicy := users.DeletionPolicy{
+		GracePeriod:     cfg.AccountDeletionGracePeriod,
+		TombstonePeriod: cfg.AccountTombstonePeriod,
+	}
+	purger := users.NewPurger(db, deletionPolicy, auditLogger, webhookDispatcher)
+	scheduler.Register("purge-deleted-accounts", cfg.AccountPurgeInterval, purger.PurgeDue)
+	scheduler.Register("delete-expired-account-tombstones", cfg.AccountPurgeInterval, purger.DeleteExpiredTombstones)
+	go scheduler.Run(context.Background())
+
+	usersService, err := users.NewUsersService(db, accessKey, refreshKey, cfg.IssuerUrl, cfg.ReauthMaxAge, emailService, emailVerifier, auditLogger, webhookDispatcher, passwordHasher, passwordPolicy, deletionPolicy, exporter)
 	if err != nil {
 		log.Fatalf("failed to create users service: %v", err)
 	}
 	r.Mount("/users", users.Router(usersService))
 
+	adminService, err := admin.NewAdminService(db, accessKey, cfg.IssuerUrl, auditLogger, passwordHasher, scheduler)
+	if err != nil {
+		log.Fatalf("failed to create admin service: %v", err)
+	}
+	r.Mount("/admin", admin.Router(adminService))
+
+	scimService, err := scim.NewScimService(db, cfg.IssuerUrl, passwordHasher, passwordPolicy, auditLogger, webhookDispatcher, cfg.AccountTombstonePeriod)
+	if err != nil {
+		log.Fatalf("failed to create scim service: %v", err)
+	}
+	r.Mount("/scim/v2", scim.Router(scimService))
+
+	if cfg.ResendWebhookSecret != "" {
+		r.Mount("/emails/webhooks", emails.WebhookRouter(emailService, cfg.ResendWebhookSecret))
+	}
+
+	if cfg.DevMailInbox {
+		if cfg.EmailTransport != "file" {
+			log.Fatal("DEV_MAIL_INBOX requires EMAIL_TRANSPORT to be file")
+		}
+		log.Printf("[WARN] Development mail inbox enabled at %s, do not use in production", devmail.MountPath)
+		r.Mount(devmail.MountPath, devmail.Router(devmail.NewInbox(cfg.EmailFileDir)))
+	}
+
 	log.Printf("Server starting on port %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
 }
@@ -0,0 +1,19 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "is_admin" boolean NOT NULL DEFAULT false;
+-- Create "audit_events" table
+CREATE TABLE "audit_events" (
+  "id" bytea NOT NULL,
+  "actor_id" bytea NULL,
+  "user_id" bytea NULL,
+  "event_type" text NOT NULL,
+  "ip_address" inet NULL,
+  "user_agent" text NULL,
+  "request_id" text NULL,
+  "metadata" jsonb NOT NULL DEFAULT '{}'::jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_audit_events_type_created" to table: "audit_events"
+CREATE INDEX "idx_audit_events_type_created" ON "audit_events" ("event_type", "created_at");
+-- Create index "idx_audit_events_user_created" to table: "audit_events"
+CREATE INDEX "idx_audit_events_user_created" ON "audit_events" ("user_id", "created_at");
@@ -0,0 +1,9 @@
+-- Create "rate_limits" table
+CREATE TABLE "rate_limits" (
+  "key" text NOT NULL,
+  "count" integer NOT NULL,
+  "reset_at" timestamptz NOT NULL,
+  PRIMARY KEY ("key")
+);
+-- Create index "idx_rate_limits_reset_at" to table: "rate_limits"
+CREATE INDEX "idx_rate_limits_reset_at" ON "rate_limits" ("reset_at");
@@ -0,0 +1,15 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "failed_login_attempts" integer NOT NULL DEFAULT 0, ADD COLUMN "last_failed_login_at" timestamptz NULL, ADD COLUMN "locked_until" timestamptz NULL;
+-- Create "account_unlock_tokens" table
+CREATE TABLE "account_unlock_tokens" (
+  "id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "token_hash" bytea NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_account_unlock_tokens_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_account_unlock_tokens_user" to table: "account_unlock_tokens"
+CREATE INDEX "idx_account_unlock_tokens_user" ON "account_unlock_tokens" ("user_id");
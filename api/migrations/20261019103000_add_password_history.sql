@@ -0,0 +1,11 @@
+-- Create "password_history" table
+CREATE TABLE "password_history" (
+  "id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "password_hash" text NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_password_history_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_password_history_user" to table: "password_history"
+CREATE INDEX "idx_password_history_user" ON "password_history" ("user_id");
@@ -0,0 +1,50 @@
+-- Create "scim_tenants" table
+CREATE TABLE "scim_tenants" (
+  "id" bytea NOT NULL,
+  "name" text NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_scim_tenants_name_key" to table: "scim_tenants"
+CREATE UNIQUE INDEX "idx_scim_tenants_name_key" ON "scim_tenants" ("name");
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "active" boolean NOT NULL DEFAULT true, ADD COLUMN "external_id" text NULL, ADD COLUMN "scim_tenant_id" bytea NULL, ADD CONSTRAINT "fk_users_scim_tenant_id" FOREIGN KEY ("scim_tenant_id") REFERENCES "scim_tenants" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Create index "idx_users_scim_tenant" to table: "users"
+CREATE INDEX "idx_users_scim_tenant" ON "users" ("scim_tenant_id");
+-- Create "scim_tokens" table
+CREATE TABLE "scim_tokens" (
+  "id" bytea NOT NULL,
+  "tenant_id" bytea NOT NULL,
+  "token_hash" bytea NOT NULL,
+  "last_used_at" timestamptz NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_scim_tokens_tenant_id" FOREIGN KEY ("tenant_id") REFERENCES "scim_tenants" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_scim_tokens_token_hash_key" to table: "scim_tokens"
+CREATE UNIQUE INDEX "idx_scim_tokens_token_hash_key" ON "scim_tokens" ("token_hash");
+-- Create "groups" table
+CREATE TABLE "groups" (
+  "id" bytea NOT NULL,
+  "tenant_id" bytea NOT NULL,
+  "display_name" text NOT NULL,
+  "external_id" text NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_groups_tenant_id" FOREIGN KEY ("tenant_id") REFERENCES "scim_tenants" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_groups_tenant_display_name_key" to table: "groups"
+CREATE UNIQUE INDEX "idx_groups_tenant_display_name_key" ON "groups" ("tenant_id", "display_name");
+-- Create "group_members" table
+CREATE TABLE "group_members" (
+  "group_id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("group_id", "user_id"),
+  CONSTRAINT "fk_group_members_group_id" FOREIGN KEY ("group_id") REFERENCES "groups" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_group_members_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_group_members_user" to table: "group_members"
+CREATE INDEX "idx_group_members_user" ON "group_members" ("user_id");
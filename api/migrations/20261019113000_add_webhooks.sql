@@ -0,0 +1,33 @@
+-- Create "webhook_subscriptions" table
+CREATE TABLE "webhook_subscriptions" (
+  "id" bytea NOT NULL,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "event_types" jsonb NOT NULL DEFAULT '[]'::jsonb,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create "webhook_deliveries" table
+CREATE TABLE "webhook_deliveries" (
+  "id" bytea NOT NULL,
+  "subscription_id" bytea NOT NULL,
+  "event_id" bytea NOT NULL,
+  "event_type" text NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" text NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL,
+  "last_attempt_at" timestamptz NULL,
+  "response_status" integer NULL,
+  "last_error" text NULL,
+  "delivered_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_webhook_deliveries_subscription_id" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_webhook_deliveries_due" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_due" ON "webhook_deliveries" ("status", "next_attempt_at");
+-- Create index "idx_webhook_deliveries_subscription" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_subscription" ON "webhook_deliveries" ("subscription_id", "id");
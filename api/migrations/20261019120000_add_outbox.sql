@@ -0,0 +1,15 @@
+-- Create "outbox" table
+CREATE TABLE "outbox" (
+  "id" bytea NOT NULL,
+  "kind" text NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" text NOT NULL,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL,
+  "last_error" text NULL,
+  "processed_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_outbox_due" to table: "outbox"
+CREATE INDEX "idx_outbox_due" ON "outbox" ("status", "next_attempt_at");
@@ -0,0 +1,24 @@
+-- Create "email_messages" table
+CREATE TABLE "email_messages" (
+  "id" bytea NOT NULL,
+  "template" text NOT NULL,
+  "recipient" text NOT NULL,
+  "provider_message_id" text NULL,
+  "status" text NOT NULL,
+  "detail" text NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_email_messages_provider_message_id_key" to table: "email_messages"
+CREATE UNIQUE INDEX "idx_email_messages_provider_message_id_key" ON "email_messages" ("provider_message_id");
+-- Create index "idx_email_messages_recipient" to table: "email_messages"
+CREATE INDEX "idx_email_messages_recipient" ON "email_messages" ("recipient", "id");
+-- Create "email_suppressions" table
+CREATE TABLE "email_suppressions" (
+  "address" text NOT NULL,
+  "reason" text NOT NULL,
+  "detail" text NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("address")
+);
@@ -0,0 +1,22 @@
+-- Create "email_change_requests" table
+CREATE TABLE "email_change_requests" (
+  "id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "previous_email" text NOT NULL,
+  "new_email" text NOT NULL,
+  "confirm_token_hash" bytea NOT NULL,
+  "cancel_token_hash" bytea NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "cancel_expires_at" timestamptz NOT NULL,
+  "confirmed_at" timestamptz NULL,
+  "cancelled_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_email_change_requests_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_email_change_requests_user" to table: "email_change_requests"
+CREATE INDEX "idx_email_change_requests_user" ON "email_change_requests" ("user_id");
+-- Create index "idx_email_change_requests_confirm_token_hash_key" to table: "email_change_requests"
+CREATE UNIQUE INDEX "idx_email_change_requests_confirm_token_hash_key" ON "email_change_requests" ("confirm_token_hash");
+-- Create index "idx_email_change_requests_cancel_token_hash_key" to table: "email_change_requests"
+CREATE UNIQUE INDEX "idx_email_change_requests_cancel_token_hash_key" ON "email_change_requests" ("cancel_token_hash");
@@ -0,0 +1,29 @@
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "delete_after" timestamptz NULL;
+-- Create index "idx_users_delete_after" to table: "users"
+CREATE INDEX "idx_users_delete_after" ON "users" ("delete_after");
+-- Create "account_deletion_tokens" table
+CREATE TABLE "account_deletion_tokens" (
+  "id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "token_hash" bytea NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_account_deletion_tokens_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_account_deletion_tokens_user" to table: "account_deletion_tokens"
+CREATE INDEX "idx_account_deletion_tokens_user" ON "account_deletion_tokens" ("user_id");
+-- Create index "idx_account_deletion_tokens_token_hash_key" to table: "account_deletion_tokens"
+CREATE UNIQUE INDEX "idx_account_deletion_tokens_token_hash_key" ON "account_deletion_tokens" ("token_hash");
+-- Create "account_tombstones" table
+CREATE TABLE "account_tombstones" (
+  "value_hash" bytea NOT NULL,
+  "kind" text NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("value_hash")
+);
+-- Create index "idx_account_tombstones_expires_at" to table: "account_tombstones"
+CREATE INDEX "idx_account_tombstones_expires_at" ON "account_tombstones" ("expires_at");
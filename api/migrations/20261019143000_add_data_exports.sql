@@ -0,0 +1,19 @@
+-- Create "data_exports" table
+CREATE TABLE "data_exports" (
+  "id" bytea NOT NULL,
+  "user_id" bytea NOT NULL,
+  "format" text NOT NULL,
+  "status" text NOT NULL,
+  "archive" bytea NULL,
+  "token_hash" bytea NULL,
+  "expires_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  "downloaded_at" timestamptz NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_data_exports_user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_data_exports_user" to table: "data_exports"
+CREATE INDEX "idx_data_exports_user" ON "data_exports" ("user_id");
+-- Create index "idx_data_exports_token_hash_key" to table: "data_exports"
+CREATE UNIQUE INDEX "idx_data_exports_token_hash_key" ON "data_exports" ("token_hash");
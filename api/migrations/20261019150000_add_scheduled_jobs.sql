@@ -0,0 +1,12 @@
+-- Modify "refresh_tokens" table
+ALTER TABLE "refresh_tokens" DROP CONSTRAINT "fk_refresh_tokens_parent_id", ADD CONSTRAINT "fk_refresh_tokens_parent_id" FOREIGN KEY ("parent_id") REFERENCES "refresh_tokens" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Create "scheduled_jobs" table
+CREATE TABLE "scheduled_jobs" (
+  "name" text NOT NULL,
+  "last_started_at" timestamptz NULL,
+  "last_finished_at" timestamptz NULL,
+  "last_status" text NULL,
+  "last_processed" bigint NULL,
+  "last_error" text NULL,
+  PRIMARY KEY ("name")
+);
@@ -0,0 +1,29 @@
+-- Modify "email_messages" table
+ALTER TABLE "email_messages" ADD COLUMN "user_id" bytea NULL;
+-- Create index "idx_email_messages_user" to table: "email_messages"
+CREATE INDEX "idx_email_messages_user" ON "email_messages" ("user_id", "id");
+-- Attribute mail sent before now to whoever held the address at the time
+WITH "changes" AS (
+  SELECT "user_id", "previous_email", "confirmed_at",
+    lag("confirmed_at") OVER (PARTITION BY "user_id" ORDER BY "confirmed_at") AS "previous_confirmed_at"
+  FROM "email_change_requests"
+  WHERE "confirmed_at" IS NOT NULL
+), "held" AS (
+  -- Each address given up, from sign-up or the change before
+  SELECT "changes"."user_id", "changes"."previous_email" AS "email",
+    coalesce("changes"."previous_confirmed_at", "users"."created_at") AS "held_from",
+    "changes"."confirmed_at" AS "held_until"
+  FROM "changes" JOIN "users" ON "users"."id" = "changes"."user_id"
+  UNION ALL
+  -- The current address, from sign-up or the last change
+  SELECT "users"."id", "users"."email",
+    coalesce(max("changes"."confirmed_at"), "users"."created_at"),
+    'infinity'::timestamptz
+  FROM "users" LEFT JOIN "changes" ON "changes"."user_id" = "users"."id"
+  GROUP BY "users"."id"
+)
+UPDATE "email_messages" SET "user_id" = "held"."user_id"
+FROM "held"
+WHERE "email_messages"."recipient" = "held"."email"
+  AND "email_messages"."created_at" >= "held"."held_from"
+  AND "email_messages"."created_at" < "held"."held_until";
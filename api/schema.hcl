@@ -23,6 +23,46 @@ table "users" {
     type = boolean
     null = false
   }
+  column "is_admin" {
+    type    = boolean
+    default = false
+    null    = false
+  }
+  column "failed_login_attempts" {
+    type    = integer
+    default = 0
+    null    = false
+  }
+  column "last_failed_login_at" {
+    type = timestamptz
+    null = true
+  }
+  column "locked_until" {
+    type = timestamptz
+    null = true
+  }
+  column "active" {
+    type    = boolean
+    default = true
+    null    = false
+  }
+  column "external_id" {
+    type = text
+    null = true
+  }
+  column "scim_tenant_id" {
+    type = bytea
+    null = true
+  }
+  column "locale" {
+    type    = text
+    default = "en"
+    null    = false
+  }
+  column "delete_after" {
+    type = timestamptz
+    null = true
+  }
   column "created_at" {
     type = timestamptz
     default = sql("now()")
@@ -45,6 +85,17 @@ table "users" {
     unique  = true
     columns = [column.username]
   }
+  index "idx_users_delete_after" {
+    columns = [column.delete_after]
+  }
+  index "idx_users_scim_tenant" {
+    columns = [column.scim_tenant_id]
+  }
+  foreign_key "fk_users_scim_tenant_id" {
+    columns = [column.scim_tenant_id]
+    ref_columns = [table.scim_tenants.column.id]
+    on_delete = SET_NULL
+  }
 }
 
 table "refresh_tokens" {
@@ -89,7 +140,7 @@ table "refresh_tokens" {
   foreign_key "fk_refresh_tokens_parent_id" {
     columns = [column.parent_id]
     ref_columns = [table.refresh_tokens.column.id]
-    on_delete = "CASCADE"
+    on_delete = SET_NULL
   }
   foreign_key "fk_refresh_tokens_user_id" {
     columns = [column.user_id]
@@ -181,3 +232,770 @@ table "email_verification_tokens" {
     columns = [column.user_id]
   }
 }
+
+table "audit_events" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "actor_id" {
+    type = bytea
+    null = true
+  }
+  column "user_id" {
+    type = bytea
+    null = true
+  }
+  column "event_type" {
+    type = text
+    null = false
+  }
+  column "ip_address" {
+    type = inet
+    null = true
+  }
+  column "user_agent" {
+    type = text
+    null = true
+  }
+  column "request_id" {
+    type = text
+    null = true
+  }
+  column "metadata" {
+    type    = jsonb
+    default = sql("'{}'::jsonb")
+    null    = false
+  }
+  column "created_at" {
+    type    = timestamptz
+    default = sql("now()")
+    null    = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_audit_events_user_created" {
+    columns = [column.user_id, column.created_at]
+  }
+  index "idx_audit_events_type_created" {
+    columns = [column.event_type, column.created_at]
+  }
+}
+
+table "rate_limits" {
+  schema = schema.public
+
+  column "key" {
+    type = text
+    null = false
+  }
+  column "count" {
+    type = integer
+    null = false
+  }
+  column "reset_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.key]
+  }
+  index "idx_rate_limits_reset_at" {
+    columns = [column.reset_at]
+  }
+}
+
+table "account_unlock_tokens" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "token_hash" {
+    type = bytea
+    null = false
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "revoked_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_account_unlock_tokens_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_account_unlock_tokens_user" {
+    columns = [column.user_id]
+  }
+}
+
+table "password_history" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "password_hash" {
+    type = text
+    null = false
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_password_history_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_password_history_user" {
+    columns = [column.user_id]
+  }
+}
+
+table "scim_tenants" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "name" {
+    type = text
+    null = false
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_scim_tenants_name_key" {
+    unique  = true
+    columns = [column.name]
+  }
+}
+
+table "scim_tokens" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "tenant_id" {
+    type = bytea
+    null = false
+  }
+  column "token_hash" {
+    type = bytea
+    null = false
+  }
+  column "last_used_at" {
+    type = timestamptz
+    null = true
+  }
+  column "revoked_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_scim_tokens_tenant_id" {
+    columns = [column.tenant_id]
+    ref_columns = [table.scim_tenants.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_scim_tokens_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+}
+
+table "groups" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "tenant_id" {
+    type = bytea
+    null = false
+  }
+  column "display_name" {
+    type = text
+    null = false
+  }
+  column "external_id" {
+    type = text
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_groups_tenant_id" {
+    columns = [column.tenant_id]
+    ref_columns = [table.scim_tenants.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_groups_tenant_display_name_key" {
+    unique  = true
+    columns = [column.tenant_id, column.display_name]
+  }
+}
+
+table "group_members" {
+  schema = schema.public
+
+  column "group_id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.group_id, column.user_id]
+  }
+  foreign_key "fk_group_members_group_id" {
+    columns = [column.group_id]
+    ref_columns = [table.groups.column.id]
+    on_delete = CASCADE
+  }
+  foreign_key "fk_group_members_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_group_members_user" {
+    columns = [column.user_id]
+  }
+}
+
+table "webhook_subscriptions" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "url" {
+    type = text
+    null = false
+  }
+  column "secret" {
+    type = text
+    null = false
+  }
+  column "event_types" {
+    type    = jsonb
+    default = sql("'[]'::jsonb")
+  }
+  column "active" {
+    type    = boolean
+    default = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+}
+
+table "webhook_deliveries" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "subscription_id" {
+    type = bytea
+    null = false
+  }
+  column "event_id" {
+    type = bytea
+    null = false
+  }
+  column "event_type" {
+    type = text
+    null = false
+  }
+  column "payload" {
+    type = jsonb
+    null = false
+  }
+  column "status" {
+    type = text
+    null = false
+  }
+  column "attempts" {
+    type    = integer
+    default = 0
+  }
+  column "next_attempt_at" {
+    type = timestamptz
+    null = false
+  }
+  column "last_attempt_at" {
+    type = timestamptz
+    null = true
+  }
+  column "response_status" {
+    type = integer
+    null = true
+  }
+  column "last_error" {
+    type = text
+    null = true
+  }
+  column "delivered_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_webhook_deliveries_subscription_id" {
+    columns = [column.subscription_id]
+    ref_columns = [table.webhook_subscriptions.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_webhook_deliveries_due" {
+    columns = [column.status, column.next_attempt_at]
+  }
+  index "idx_webhook_deliveries_subscription" {
+    columns = [column.subscription_id, column.id]
+  }
+}
+
+table "outbox" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "kind" {
+    type = text
+    null = false
+  }
+  column "payload" {
+    type = jsonb
+    null = false
+  }
+  column "status" {
+    type = text
+    null = false
+  }
+  column "attempts" {
+    type    = integer
+    default = 0
+  }
+  column "next_attempt_at" {
+    type = timestamptz
+    null = false
+  }
+  column "last_error" {
+    type = text
+    null = true
+  }
+  column "processed_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_outbox_due" {
+    columns = [column.status, column.next_attempt_at]
+  }
+}
+
+table "email_messages" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "template" {
+    type = text
+    null = false
+  }
+  column "recipient" {
+    type = text
+    null = false
+  }
+  column "provider_message_id" {
+    type = text
+    null = true
+  }
+  column "status" {
+    type = text
+    null = false
+  }
+  column "detail" {
+    type = text
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = true
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  index "idx_email_messages_provider_message_id_key" {
+    unique  = true
+    columns = [column.provider_message_id]
+  }
+  index "idx_email_messages_recipient" {
+    columns = [column.recipient, column.id]
+  }
+  index "idx_email_messages_user" {
+    columns = [column.user_id, column.id]
+  }
+}
+
+table "email_suppressions" {
+  schema = schema.public
+
+  column "address" {
+    type = text
+    null = false
+  }
+  column "reason" {
+    type = text
+    null = false
+  }
+  column "detail" {
+    type = text
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.address]
+  }
+}
+
+table "email_change_requests" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "previous_email" {
+    type = text
+    null = false
+  }
+  column "new_email" {
+    type = text
+    null = false
+  }
+  column "confirm_token_hash" {
+    type = bytea
+    null = false
+  }
+  column "cancel_token_hash" {
+    type = bytea
+    null = false
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "cancel_expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "confirmed_at" {
+    type = timestamptz
+    null = true
+  }
+  column "cancelled_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_email_change_requests_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_email_change_requests_user" {
+    columns = [column.user_id]
+  }
+  index "idx_email_change_requests_confirm_token_hash_key" {
+    unique  = true
+    columns = [column.confirm_token_hash]
+  }
+  index "idx_email_change_requests_cancel_token_hash_key" {
+    unique  = true
+    columns = [column.cancel_token_hash]
+  }
+}
+
+table "account_deletion_tokens" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "token_hash" {
+    type = bytea
+    null = false
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "revoked_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_account_deletion_tokens_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_account_deletion_tokens_user" {
+    columns = [column.user_id]
+  }
+  index "idx_account_deletion_tokens_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+}
+
+table "account_tombstones" {
+  schema = schema.public
+
+  column "value_hash" {
+    type = bytea
+    null = false
+  }
+  column "kind" {
+    type = text
+    null = false
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = false
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.value_hash]
+  }
+  index "idx_account_tombstones_expires_at" {
+    columns = [column.expires_at]
+  }
+}
+
+table "data_exports" {
+  schema = schema.public
+
+  column "id" {
+    type = bytea
+    null = false
+  }
+  column "user_id" {
+    type = bytea
+    null = false
+  }
+  column "format" {
+    type = text
+    null = false
+  }
+  column "status" {
+    type = text
+    null = false
+  }
+  column "archive" {
+    type = bytea
+    null = true
+  }
+  column "token_hash" {
+    type = bytea
+    null = true
+  }
+  column "expires_at" {
+    type = timestamptz
+    null = true
+  }
+  column "completed_at" {
+    type = timestamptz
+    null = true
+  }
+  column "downloaded_at" {
+    type = timestamptz
+    null = true
+  }
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "fk_data_exports_user_id" {
+    columns = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_delete = CASCADE
+  }
+  index "idx_data_exports_user" {
+    columns = [column.user_id]
+  }
+  index "idx_data_exports_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+}
+
+table "scheduled_jobs" {
+  schema = schema.public
+
+  column "name" {
+    type = text
+    null = false
+  }
+  column "last_started_at" {
+    type = timestamptz
+    null = true
+  }
+  column "last_finished_at" {
+    type = timestamptz
+    null = true
+  }
+  column "last_status" {
+    type = text
+    null = true
+  }
+  column "last_processed" {
+    type = bigint
+    null = true
+  }
+  column "last_error" {
+    type = text
+    null = true
+  }
+
+  primary_key {
+    columns = [column.name]
+  }
+}